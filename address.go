@@ -0,0 +1,78 @@
+package workspace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	workspaceArgDesc = "Workspace number (N), relative offset (+N or -N), first (^), or last ($, last, $-N)"
+)
+
+// wrapWorkspace returns the workspace index i wrapped around n workspaces.
+func wrapWorkspace(i, n int) int {
+	for ; i < 0; i += n {
+	}
+	return i % n
+}
+
+// resolveWorkspace converts the WORKSPACE argument into a workspace index.
+func resolveWorkspace(d *command.Data, e env) (int, error) {
+	return resolveWorkspaceSpec(d.String(workspaceArg), e)
+}
+
+// resolveWorkspaceSpec converts a workspace specification (see
+// workspaceArgDesc) into a workspace index.
+func resolveWorkspaceSpec(spec string, e env) (int, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "^":
+		return 0, nil
+	case spec == "$" || spec == "last":
+		n, err := e.numWorkspaces()
+		if err != nil {
+			return 0, err
+		}
+		return n - 1, nil
+	case strings.HasPrefix(spec, "$-") || strings.HasPrefix(spec, "last-"):
+		offset, err := strconv.Atoi(spec[strings.Index(spec, "-")+1:])
+		if err != nil || offset < 0 {
+			return 0, invalidWorkspace(spec)
+		}
+		n, err := e.numWorkspaces()
+		if err != nil {
+			return 0, err
+		}
+		if offset >= n {
+			return 0, &WorkspaceNotFoundError{Workspace: n - 1 - offset, NumWorkspaces: n, Spec: spec}
+		}
+		return n - 1 - offset, nil
+	case strings.HasPrefix(spec, "+") || strings.HasPrefix(spec, "-"):
+		offset, err := strconv.Atoi(spec)
+		if err != nil {
+			return 0, invalidWorkspace(spec)
+		}
+		n, err := e.numWorkspaces()
+		if err != nil {
+			return 0, err
+		}
+		c, err := e.currentWorkspace()
+		if err != nil {
+			return 0, err
+		}
+		return wrapWorkspace(c+offset, n), nil
+	}
+
+	i, err := strconv.Atoi(spec)
+	if err != nil || i < 0 {
+		return 0, invalidWorkspace(spec)
+	}
+	return i, nil
+}
+
+func invalidWorkspace(spec string) error {
+	return fmt.Errorf("invalid workspace %q: must be N, +N, -N, ^, $, last, or $-N", spec)
+}
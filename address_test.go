@@ -0,0 +1,66 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveWorkspaceSpec(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		spec    string
+		want    int
+		wantErr error
+	}{
+		{
+			name: "resolves last",
+			spec: "$",
+			want: 3,
+		},
+		{
+			name: "resolves counted from the end",
+			spec: "last-2",
+			want: 1,
+		},
+		{
+			name: "resolves relative offset",
+			spec: "-2",
+			want: 3,
+		},
+		{
+			name:    "fails if counted from the end past the first workspace",
+			spec:    "$-4",
+			wantErr: &WorkspaceNotFoundError{Workspace: -1, NumWorkspaces: 4, Spec: "$-4"},
+		},
+		{
+			name:    "fails for invalid workspace",
+			spec:    "$-x",
+			wantErr: invalidWorkspace("$-x"),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fr := &fakeRunner{responses: map[string][]string{
+				dq: numberedDesktops(4, 1),
+			}}
+			e := &runnerEnv{ctx: context.Background(), runner: fr, warnings: io.Discard}
+			got, err := resolveWorkspaceSpec(test.spec, e)
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("resolveWorkspaceSpec(%q) returned unexpected error (-want, +got):\n%s", test.spec, diff)
+			}
+			if err != nil {
+				var wnf *WorkspaceNotFoundError
+				if _, ok := test.wantErr.(*WorkspaceNotFoundError); ok && !errors.As(err, &wnf) {
+					t.Errorf("resolveWorkspaceSpec(%q) returned %T, want *WorkspaceNotFoundError", test.spec, err)
+				}
+				return
+			}
+			if got != test.want {
+				t.Errorf("resolveWorkspaceSpec(%q) returned %d, want %d", test.spec, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Runner runs a bash script and returns its stdout split into lines.
+type Runner interface {
+	Run(ctx context.Context, script string) ([]string, error)
+}
+
+// BashRunner is a Runner that runs scripts with bash.
+type BashRunner struct{}
+
+func (BashRunner) Run(ctx context.Context, script string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-c", strings.Join([]string{"set -e", "set -o pipefail", script}, "\n"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	s := strings.TrimSuffix(string(out), "\n")
+	if s == "" {
+		return nil, nil
+	}
+	return strings.Split(s, "\n"), nil
+}
+
+// Client switches workspaces and sets brightness directly, rather than
+// returning executables for the shell to run like the CLI does. Changes are
+// made to the provided Workspace, which can then be saved by the caller.
+type Client struct {
+	// Workspace is the state that is read and updated by the client.
+	Workspace *Workspace
+	// Runner runs all queries and commands. Defaults to BashRunner.
+	Runner Runner
+	// Warnings is where problems that don't cause an operation to fail are
+	// written. Defaults to os.Stderr.
+	Warnings io.Writer
+	// Display is the X display to use (e.g. ":1"). Defaults to $DISPLAY.
+	// Each display has its own state in Workspace.
+	Display string
+}
+
+// NewClient returns a Client for the provided workspace that runs
+// commands with bash.
+func NewClient(w *Workspace) *Client {
+	return &Client{Workspace: w}
+}
+
+// Switch moves to workspace n. If the workspace is PerMonitor, n is the
+// position of the workspace on the focused monitor.
+func (c *Client) Switch(ctx context.Context, n int) error {
+	return c.do(ctx, func(w *Workspace, e *runnerEnv) ([]string, error) {
+		if w.PerMonitor {
+			return w.moveOnOutput(e, func(*outputEnv) (int, error) { return n, nil })
+		}
+		num, err := e.numWorkspaces()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= num {
+			return nil, &WorkspaceNotFoundError{Workspace: n, NumWorkspaces: num}
+		}
+		return w.moveTo(n, e)
+	})
+}
+
+// Relative moves offset workspaces to the right (or left if offset is
+// negative), wrapping around at either end.
+func (c *Client) Relative(ctx context.Context, offset int) error {
+	return c.do(ctx, func(w *Workspace, e *runnerEnv) ([]string, error) {
+		return w.moveRelative(offset, e)
+	})
+}
+
+// Back moves to the previous workspace.
+func (c *Client) Back(ctx context.Context) error {
+	return c.do(ctx, func(w *Workspace, e *runnerEnv) ([]string, error) {
+		if w.PerMonitor {
+			return w.moveBackOnOutput(e)
+		}
+		return w.moveTo(w.prev(), e)
+	})
+}
+
+// SetBrightness sets the brightness percentage for workspace ws. The new
+// brightness is applied the next time the workspace is switched to.
+func (c *Client) SetBrightness(ctx context.Context, ws, pct int) error {
+	e := c.env(ctx)
+	return c.Workspace.update(c.Display, func(*int) error {
+		num, err := e.numWorkspaces()
+		if err != nil {
+			return err
+		}
+		if ws < 0 || ws >= num {
+			return &WorkspaceNotFoundError{Workspace: ws, NumWorkspaces: num}
+		}
+		s := c.state()
+		return s.journal(func() error {
+			return s.setBrightnessFor(ws, pct)
+		})
+	})
+}
+
+// do runs f as part of an update to the workspace state and then runs the
+// commands that f returns.
+func (c *Client) do(ctx context.Context, f func(*Workspace, *runnerEnv) ([]string, error)) error {
+	e := c.env(ctx)
+	var r []string
+	err := c.Workspace.update(c.Display, func(pending *int) error {
+		e.current = pending
+		w := c.state()
+		w.switched = nil
+		var err error
+		r, err = f(w, e)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return e.runAll(r)
+}
+
+// state returns the state for the client's display.
+func (c *Client) state() *Workspace {
+	return c.Workspace.forDisplay(c.Display)
+}
+
+func (c *Client) env(ctx context.Context) *runnerEnv {
+	r := c.Runner
+	if r == nil {
+		r = BashRunner{}
+	}
+	warnings := c.Warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+	return &runnerEnv{ctx: ctx, runner: r, warnings: warnings, display: c.Display}
+}
+
+// runnerEnv is the env used by Client. Each query is run at most once.
+type runnerEnv struct {
+	ctx      context.Context
+	runner   Runner
+	warnings io.Writer
+	display  string
+	// current overrides the current workspace, if set.
+	current *int
+	cache   map[string][]string
+}
+
+func (e *runnerEnv) run(script string) ([]string, error) {
+	s := script
+	if e.display != "" {
+		s = fmt.Sprintf("export DISPLAY=%q\n%s", e.display, script)
+	}
+	r, err := e.runner.Run(e.ctx, s)
+	if err != nil {
+		return nil, &CommandError{script, err}
+	}
+	return r, nil
+}
+
+func (e *runnerEnv) runAll(scripts []string) error {
+	for _, script := range scripts {
+		if _, err := e.run(script); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *runnerEnv) query(contents []string) ([]string, error) {
+	script := strings.Join(contents, "\n")
+	if r, ok := e.cache[script]; ok {
+		return r, nil
+	}
+	r, err := e.run(script)
+	if err != nil {
+		return nil, err
+	}
+	if e.cache == nil {
+		e.cache = map[string][]string{}
+	}
+	e.cache[script] = r
+	return r, nil
+}
+
+func (e *runnerEnv) queryInt(contents []string) (int, error) {
+	lines, err := e.query(contents)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, &CommandError{strings.Join(contents, "\n"), fmt.Errorf("no output")}
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return 0, &CommandError{strings.Join(contents, "\n"), err}
+	}
+	return i, nil
+}
+
+func (e *runnerEnv) desktops() ([]*desktop, error) {
+	lines, err := e.query(listDesktops.Contents)
+	if err != nil {
+		return nil, err
+	}
+	return parseDesktops(lines)
+}
+
+func (e *runnerEnv) numWorkspaces() (int, error) {
+	ds, err := e.desktops()
+	if err != nil {
+		return 0, err
+	}
+	return countDesktops(ds)
+}
+
+func (e *runnerEnv) currentWorkspace() (int, error) {
+	if e.current != nil {
+		return *e.current, nil
+	}
+	ds, err := e.desktops()
+	if err != nil {
+		return 0, err
+	}
+	return currentDesktop(ds)
+}
+
+func (e *runnerEnv) monitors() ([]string, error) {
+	return e.query(listMcs.Contents)
+}
+
+func (e *runnerEnv) windows() ([]string, error) {
+	return e.query(listWindows.Contents)
+}
+
+func (e *runnerEnv) outputWorkspaces() ([]string, error) {
+	return e.query(listOutputWorkspaces.Contents)
+}
+
+func (e *runnerEnv) warn(err error, msg string) {
+	fmt.Fprintf(e.warnings, "%s: %v\n", msg, err)
+}
@@ -0,0 +1,514 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/leep-frog/workspace/workspacetest"
+)
+
+type fakeRunner struct {
+	responses map[string][]string
+	errs      map[string]error
+	got       []string
+}
+
+func (fr *fakeRunner) Run(ctx context.Context, script string) ([]string, error) {
+	fr.got = append(fr.got, script)
+	if err, ok := fr.errs[script]; ok {
+		return nil, err
+	}
+	return fr.responses[script], nil
+}
+
+func TestClient(t *testing.T) {
+	i3Workspaces := []string{
+		`[{"num":1,"name":"1","visible":true,"focused":false,"output":"HDMI-1"},`,
+		`{"num":2,"name":"2","visible":true,"focused":true,"output":"eDP-1"},`,
+		`{"num":5,"name":"5","visible":false,"focused":false,"output":"eDP-1"},`,
+		`{"num":7,"name":"7: mail","visible":false,"focused":false,"output":"eDP-1"}]`,
+	}
+	// i3 names each desktop after its workspace.
+	i3Desktops := desktopLines(1, "1", "2", "5", "7: mail")
+
+	for _, test := range []struct {
+		name         string
+		w            *Workspace
+		display      string
+		f            func(*Client) error
+		responses    map[string][]string
+		errs         map[string]error
+		want         *Workspace
+		wantErr      error
+		wantRun      []string
+		wantWarnings string
+	}{
+		{
+			name: "switches workspace",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+			},
+			f: func(c *Client) error { return c.Switch(context.Background(), 2) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 1),
+				lm: {"DP-1", "eDP-1"},
+			},
+			wantRun: []string{
+				dq,
+				lm,
+				"wmctrl -s 2",
+				"xrandr --output DP-1 --brightness 0.40",
+				"xrandr --output eDP-1 --brightness 0.40",
+			},
+			want: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+			},
+		},
+		{
+			name: "switch fails for nonexistent workspace",
+			f:    func(c *Client) error { return c.Switch(context.Background(), 4) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+			},
+			wantRun: []string{dq},
+			wantErr: &WorkspaceNotFoundError{Workspace: 4, NumWorkspaces: 4},
+		},
+		{
+			name: "switch fails if no workspaces",
+			f:    func(c *Client) error { return c.Switch(context.Background(), 0) },
+			responses: map[string][]string{
+				dq: numberedDesktops(0, 0),
+			},
+			wantRun: []string{dq},
+			wantErr: ErrNoWorkspaces,
+		},
+		{
+			name: "moves relative and warns if monitors can't be found",
+			f:    func(c *Client) error { return c.Relative(context.Background(), -2) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 1),
+			},
+			errs: map[string]error{
+				lm: fmt.Errorf("no xrandr"),
+			},
+			wantRun: []string{
+				dq,
+				lm,
+				"wmctrl -s 3",
+			},
+			wantWarnings: fmt.Sprintf("Failed to get monitor codes: failed to run %q: no xrandr\n", lm),
+			want: &Workspace{
+				Prev: intPtr(1),
+			},
+		},
+		{
+			name: "moves back",
+			w: &Workspace{
+				Prev: intPtr(3),
+			},
+			f: func(c *Client) error { return c.Back(context.Background()) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+				lm: {"DP-1"},
+			},
+			wantRun: []string{
+				dq,
+				lm,
+				"wmctrl -s 3",
+				"xrandr --output DP-1 --brightness 1.00",
+			},
+			want: &Workspace{
+				Prev: intPtr(0),
+			},
+		},
+		{
+			name: "back fails if no workspace is current",
+			f:    func(c *Client) error { return c.Back(context.Background()) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, -1),
+			},
+			wantRun: []string{dq},
+			wantErr: ErrNoCurrentWorkspace,
+		},
+		{
+			name: "returns command error",
+			f:    func(c *Client) error { return c.Back(context.Background()) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 2),
+			},
+			errs: map[string]error{
+				"wmctrl -s 0": fmt.Errorf("oops"),
+			},
+			wantRun: []string{
+				dq,
+				lm,
+				"wmctrl -s 0",
+			},
+			wantErr: &CommandError{"wmctrl -s 0", fmt.Errorf("oops")},
+			want: &Workspace{
+				Prev: intPtr(2),
+			},
+		},
+		{
+			name: "switches workspace on another display",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+				Displays: map[string]*Workspace{
+					":1": {
+						Profiles: map[int]*Profile{
+							2: {Brightness: 70},
+						},
+					},
+				},
+			},
+			display: ":1",
+			f:       func(c *Client) error { return c.Switch(context.Background(), 2) },
+			responses: map[string][]string{
+				"export DISPLAY=\":1\"\n" + dq: numberedDesktops(4, 1),
+				"export DISPLAY=\":1\"\n" + lm: {"VNC-0"},
+			},
+			wantRun: []string{
+				"export DISPLAY=\":1\"\n" + dq,
+				"export DISPLAY=\":1\"\n" + lm,
+				"export DISPLAY=\":1\"\nwmctrl -s 2",
+				"export DISPLAY=\":1\"\nxrandr --output VNC-0 --brightness 0.70",
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+				Displays: map[string]*Workspace{
+					":1": {
+						Prev: intPtr(1),
+						Profiles: map[int]*Profile{
+							2: {Brightness: 70},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "switches workspace on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+				Profiles: map[int]*Profile{
+					3: {Brightness: 60},
+					7: {Brightness: 20},
+				},
+			},
+			f: func(c *Client) error { return c.Switch(context.Background(), 2) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+				dq:  i3Desktops,
+			},
+			wantRun: []string{
+				i3q,
+				dq,
+				"i3-msg workspace number 7",
+				"xrandr --output eDP-1 --brightness 0.60",
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					3: {Brightness: 60},
+					7: {Brightness: 20},
+				},
+			},
+		},
+		{
+			name: "switch on the focused monitor fails if no desktop has the workspace's name",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			f: func(c *Client) error { return c.Switch(context.Background(), 1) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+				dq:  desktopLines(0, "1", "2"),
+			},
+			wantRun: []string{i3q, dq},
+			wantErr: fmt.Errorf(`no desktop is named after workspace "5"`),
+			want: &Workspace{
+				PerMonitor: true,
+			},
+		},
+		{
+			name: "switch fails for nonexistent workspace on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			f: func(c *Client) error { return c.Switch(context.Background(), 3) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+			},
+			wantRun: []string{i3q},
+			wantErr: &WorkspaceNotFoundError{Workspace: 3, NumWorkspaces: 3},
+			want: &Workspace{
+				PerMonitor: true,
+			},
+		},
+		{
+			name: "relative move fails if focused workspace has no number",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			f: func(c *Client) error { return c.Relative(context.Background(), 1) },
+			responses: map[string][]string{
+				i3q: {
+					`[{"num":1,"name":"1","visible":true,"focused":false,"output":"eDP-1"},`,
+					`{"num":-1,"name":"mail","visible":true,"focused":true,"output":"eDP-1"}]`,
+				},
+			},
+			wantRun: []string{i3q},
+			wantErr: fmt.Errorf("the focused workspace on eDP-1 has no number"),
+			want: &Workspace{
+				PerMonitor: true,
+			},
+		},
+		{
+			name: "moves relative on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			f: func(c *Client) error { return c.Relative(context.Background(), -1) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+				dq:  i3Desktops,
+			},
+			wantRun: []string{
+				i3q,
+				dq,
+				"i3-msg workspace number 7",
+				"xrandr --output eDP-1 --brightness 1.00",
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+			},
+		},
+		{
+			name: "moves back on the focused monitor",
+			w: &Workspace{
+				Prev:       intPtr(3),
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"HDMI-1": 3,
+					"eDP-1":  5,
+				},
+			},
+			f: func(c *Client) error { return c.Back(context.Background()) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+				dq:  i3Desktops,
+			},
+			wantRun: []string{
+				i3q,
+				dq,
+				"i3-msg workspace number 5",
+				"xrandr --output eDP-1 --brightness 1.00",
+			},
+			want: &Workspace{
+				Prev:       intPtr(3),
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"HDMI-1": 3,
+					"eDP-1":  2,
+				},
+			},
+		},
+		{
+			name: "back fails if the focused monitor has no previous workspace",
+			w: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"HDMI-1": 3,
+				},
+			},
+			f: func(c *Client) error { return c.Back(context.Background()) },
+			responses: map[string][]string{
+				i3q: i3Workspaces,
+			},
+			wantRun: []string{i3q},
+			wantErr: fmt.Errorf("no previous workspace for output eDP-1"),
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"HDMI-1": 3,
+				},
+			},
+		},
+		{
+			name: "sets brightness",
+			f:    func(c *Client) error { return c.SetBrightness(context.Background(), 3, 75) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+			},
+			wantRun: []string{dq},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+				},
+				Journal: &Journal{
+					Undo: []*Settings{{}},
+				},
+			},
+		},
+		{
+			name: "set brightness fails for out of range brightness",
+			f:    func(c *Client) error { return c.SetBrightness(context.Background(), 3, 251) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+			},
+			wantRun: []string{dq},
+			wantErr: &BrightnessRangeError{251},
+		},
+		{
+			name: "set brightness fails for negative workspace",
+			f:    func(c *Client) error { return c.SetBrightness(context.Background(), -1, 50) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+			},
+			wantRun: []string{dq},
+			wantErr: &WorkspaceNotFoundError{Workspace: -1, NumWorkspaces: 4},
+		},
+		{
+			name: "set brightness fails for nonexistent workspace",
+			f:    func(c *Client) error { return c.SetBrightness(context.Background(), 4, 50) },
+			responses: map[string][]string{
+				dq: numberedDesktops(4, 0),
+			},
+			wantRun: []string{dq},
+			wantErr: &WorkspaceNotFoundError{Workspace: 4, NumWorkspaces: 4},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := test.w
+			if w == nil {
+				w = &Workspace{}
+			}
+			fr := &fakeRunner{responses: test.responses, errs: test.errs}
+			warnings := &bytes.Buffer{}
+			c := &Client{w, fr, warnings, test.display}
+
+			err := test.f(c)
+			if diff := cmp.Diff(test.wantErr, err, cmpTypedErr); diff != "" {
+				t.Errorf("Client returned unexpected error (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantRun, fr.got); diff != "" {
+				t.Errorf("Client ran unexpected commands (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantWarnings, warnings.String()); diff != "" {
+				t.Errorf("Client wrote unexpected warnings (-want, +got):\n%s", diff)
+			}
+			want := test.want
+			if want == nil {
+				want = &Workspace{}
+			}
+			if diff := cmp.Diff(want, w, cmpopts.IgnoreUnexported(Workspace{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Client produced unexpected workspace (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// cmpTypedErr compares errors by type and message.
+var cmpTypedErr = cmp.Comparer(func(this, that error) bool {
+	if this == nil || that == nil {
+		return this == nil && that == nil
+	}
+	return fmt.Sprintf("%T: %v", this, this) == fmt.Sprintf("%T: %v", that, that)
+})
+
+func TestClientOnFakeDesktop(t *testing.T) {
+	d := workspacetest.New(4, "DP-1", "eDP-1")
+	d.AddWindow(0, "terminal")
+	d.AddWindow(3, "music")
+	w := &Workspace{
+		SkipEmpty: true,
+		Profiles: map[int]*Profile{
+			3: {Brightness: 40},
+		},
+	}
+	c := &Client{Workspace: w, Runner: d}
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		name           string
+		f              func() error
+		wantCurrent    int
+		wantBrightness float64
+		wantPrev       int
+	}{
+		{
+			name:           "switches workspace",
+			f:              func() error { return c.Switch(ctx, 2) },
+			wantCurrent:    2,
+			wantBrightness: 1,
+		},
+		{
+			name:           "skips empty workspaces",
+			f:              func() error { return c.Relative(ctx, 1) },
+			wantCurrent:    3,
+			wantBrightness: 0.4,
+			wantPrev:       2,
+		},
+		{
+			name:           "wraps around to the next occupied workspace",
+			f:              func() error { return c.Relative(ctx, 1) },
+			wantCurrent:    0,
+			wantBrightness: 1,
+			wantPrev:       3,
+		},
+		{
+			name:           "moves back",
+			f:              func() error { return c.Back(ctx) },
+			wantCurrent:    3,
+			wantBrightness: 0.4,
+			wantPrev:       0,
+		},
+		{
+			name: "applies new brightness on the next switch",
+			f: func() error {
+				if err := c.SetBrightness(ctx, 0, 70); err != nil {
+					return err
+				}
+				return c.Back(ctx)
+			},
+			wantCurrent:    0,
+			wantBrightness: 0.7,
+			wantPrev:       3,
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			if err := step.f(); err != nil {
+				t.Fatalf("returned error: %v", err)
+			}
+			if d.Current != step.wantCurrent {
+				t.Errorf("desktop is on workspace %d; want %d", d.Current, step.wantCurrent)
+			}
+			for _, o := range d.Outputs {
+				if o.Brightness != step.wantBrightness {
+					t.Errorf("%s has brightness %v; want %v", o.Name, o.Brightness, step.wantBrightness)
+				}
+			}
+			if diff := cmp.Diff(intPtr(step.wantPrev), w.Prev); diff != "" {
+				t.Errorf("Prev has diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
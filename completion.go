@@ -0,0 +1,90 @@
+package workspace
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	// completionRunner runs the queries needed for completion. Stubbed out
+	// for tests.
+	completionRunner Runner = BashRunner{}
+)
+
+// workspaceCompleter suggests the indices of the existing workspaces. If
+// more than one workspace matches, each suggestion is annotated with the
+// workspace's name, current marker and brightness (e.g. "1:web(40%)*").
+// Only the index is suggested once a single workspace matches, so the
+// completed argument is always a plain index.
+func (w *Workspace) workspaceCompleter() command.Completer[string] {
+	return command.CompleterFromFunc(func(prefix string, d *command.Data) (*command.Completion, error) {
+		// Show the brightness saved by other ws processes.
+		if err := w.reload(); err != nil {
+			return nil, nil
+		}
+		display := displayFlag.Get(d)
+		c := &Client{Workspace: w, Runner: completionRunner, Display: display}
+		desktops, err := c.env(context.Background()).desktops()
+		if err != nil {
+			// Don't break the user's shell if the window manager can't be
+			// reached.
+			return nil, nil
+		}
+
+		return &command.Completion{
+			Suggestions: workspaceSuggestions(w.forDisplay(display).statuses(desktops), prefix),
+		}, nil
+	})
+}
+
+// workspaceSuggestions returns the completion suggestions for the workspaces
+// whose index starts with prefix.
+func workspaceSuggestions(statuses []*workspaceStatus, prefix string) []string {
+	var matches []*workspaceStatus
+	for _, s := range statuses {
+		if strings.HasPrefix(strconv.Itoa(s.Index), prefix) {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) == 1 {
+		return []string{strconv.Itoa(matches[0].Index)}
+	}
+	var r []string
+	for _, s := range matches {
+		a := s.String()
+		if s.Current {
+			a += "*"
+		}
+		r = append(r, a)
+	}
+	return r
+}
+
+// checkWorkspace fails at parse time if the WORKSPACE argument refers to a
+// workspace that doesn't exist, rather than leaving wmctrl to fail. It must
+// come after listDesktops.
+func (w *Workspace) checkWorkspace() command.Processor {
+	return command.SuperSimpleProcessor(func(i *command.Input, d *command.Data) error {
+		// Per-monitor workspaces are checked against the focused monitor when
+		// moving.
+		if w.forDisplay(displayFlag.Get(d)).PerMonitor {
+			return nil
+		}
+		e := newCLIEnv(nil, d)
+		n, err := resolveWorkspace(d, e)
+		if err != nil {
+			return err
+		}
+		num, err := e.numWorkspaces()
+		if err != nil {
+			return err
+		}
+		if n >= num {
+			return &WorkspaceNotFoundError{Workspace: n, NumWorkspaces: num}
+		}
+		return nil
+	})
+}
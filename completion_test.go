@@ -0,0 +1,47 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWorkspaceSuggestions(t *testing.T) {
+	statuses := []*workspaceStatus{
+		{Index: 0, Name: "main", Brightness: 100},
+		{Index: 1, Name: "web", Current: true, Brightness: 40},
+		{Index: 2, Name: "chat", Brightness: 100},
+		{Index: 10, Name: "10", Brightness: 75},
+	}
+	for _, test := range []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name: "annotates every workspace",
+			want: []string{"0:main(100%)", "1:web(40%)*", "2:chat(100%)", "10:10(75%)"},
+		},
+		{
+			name:   "annotates workspaces with matching prefix",
+			prefix: "1",
+			want:   []string{"1:web(40%)*", "10:10(75%)"},
+		},
+		{
+			name:   "suggests plain index for single match",
+			prefix: "2",
+			want:   []string{"2"},
+		},
+		{
+			name:   "suggests nothing for unknown workspace",
+			prefix: "7",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := workspaceSuggestions(statuses, test.prefix)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("workspaceSuggestions(%q) returned diff (-want, +got):\n%s", test.prefix, diff)
+			}
+		})
+	}
+}
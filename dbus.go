@@ -0,0 +1,265 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/leep-frog/command"
+)
+
+const (
+	dbusName  = "org.leepfrog.Workspace"
+	dbusPath  = dbus.ObjectPath("/org/leepfrog/Workspace")
+	dbusIface = "org.leepfrog.Workspace"
+
+	propertiesIface = "org.freedesktop.DBus.Properties"
+
+	dbusPollInterval = time.Second
+)
+
+// dbusService is the org.leepfrog.Workspace D-Bus object. All of its exported
+// methods are published on the bus.
+type dbusService struct {
+	mu     sync.Mutex
+	conn   *dbus.Conn
+	client *Client
+	// current is the workspace that was last announced with
+	// WorkspaceChanged.
+	current *int32
+	// props are the property values that were last announced with
+	// PropertiesChanged.
+	props map[string]dbus.Variant
+}
+
+// Switch moves to workspace n.
+func (s *dbusService) Switch(n int32) *dbus.Error {
+	return s.move(func(ctx context.Context) error {
+		return s.client.Switch(ctx, int(n))
+	})
+}
+
+// Relative moves offset workspaces to the right (or left if negative).
+func (s *dbusService) Relative(offset int32) *dbus.Error {
+	return s.move(func(ctx context.Context) error {
+		return s.client.Relative(ctx, int(offset))
+	})
+}
+
+// Back moves to the previous workspace.
+func (s *dbusService) Back() *dbus.Error {
+	return s.move(func(ctx context.Context) error {
+		return s.client.Back(ctx)
+	})
+}
+
+// SetBrightness sets the brightness percentage for workspace ws.
+func (s *dbusService) SetBrightness(ws, pct int32) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.client.SetBrightness(context.Background(), int(ws), int(pct)); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// move runs f and emits WorkspaceChanged if f switched workspaces.
+func (s *dbusService) move(f func(context.Context) error) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := f(context.Background()); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if n := s.client.state().switched; n != nil {
+		if err := s.workspaceChanged(int32(*n)); err != nil {
+			return dbus.MakeFailedError(err)
+		}
+	}
+	return nil
+}
+
+// workspaceChanged emits WorkspaceChanged. The lock must be held.
+func (s *dbusService) workspaceChanged(n int32) error {
+	s.current = &n
+	if err := s.conn.Emit(dbusPath, dbusIface+".WorkspaceChanged", n); err != nil {
+		return fmt.Errorf("failed to emit WorkspaceChanged: %v", err)
+	}
+	return nil
+}
+
+// poll emits WorkspaceChanged and PropertiesChanged for any changes since
+// the last poll, including ones made outside of D-Bus (e.g. switches from
+// keybindings that run ws).
+func (s *dbusService) poll() error {
+	props, err := s.properties()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := props["CurrentWorkspace"].Value().(int32)
+	if s.current == nil {
+		s.current = &c
+	} else if *s.current != c {
+		if err := s.workspaceChanged(c); err != nil {
+			return err
+		}
+	}
+
+	if s.props == nil {
+		s.props = props
+		return nil
+	}
+	changed := map[string]dbus.Variant{}
+	for k, v := range props {
+		if s.props[k].String() != v.String() {
+			changed[k] = v
+		}
+	}
+	s.props = props
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := s.conn.Emit(dbusPath, propertiesIface+".PropertiesChanged", dbusIface, changed, []string{}); err != nil {
+		return fmt.Errorf("failed to emit PropertiesChanged: %v", err)
+	}
+	return nil
+}
+
+// watch polls for changes until ctx is done.
+func (s *dbusService) watch(ctx context.Context) error {
+	for {
+		if err := s.poll(); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(dbusPollInterval):
+		}
+	}
+}
+
+// properties returns the current values of all org.leepfrog.Workspace
+// properties.
+func (s *dbusService) properties() (map[string]dbus.Variant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.client.Workspace.reload(); err != nil {
+		return nil, err
+	}
+	c, err := s.client.env(context.Background()).currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	w := s.client.state()
+	return map[string]dbus.Variant{
+		"CurrentWorkspace": dbus.MakeVariant(int32(c)),
+		"Previous":         dbus.MakeVariant(int32(w.prev())),
+		"Brightness":       dbus.MakeVariant(int32(w.brightness(c))),
+	}, nil
+}
+
+// dbusProperties implements org.freedesktop.DBus.Properties for a
+// dbusService. The properties are computed when requested since workspaces
+// may be switched outside of ws.
+type dbusProperties struct {
+	s *dbusService
+}
+
+func (p *dbusProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	v, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, prop.ErrInvalidArg
+	}
+	return v, nil
+}
+
+func (p *dbusProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != dbusIface {
+		return nil, prop.ErrIfaceNotFound
+	}
+	props, err := p.s.properties()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return props, nil
+}
+
+func (p *dbusProperties) Set(iface, name string, v dbus.Variant) *dbus.Error {
+	return prop.ErrReadOnly
+}
+
+// exportDBus publishes the org.leepfrog.Workspace object for c on conn.
+func exportDBus(conn *dbus.Conn, c *Client) (*dbusService, error) {
+	s := &dbusService{conn: conn, client: c}
+	if err := conn.Export(s, dbusPath, dbusIface); err != nil {
+		return nil, fmt.Errorf("failed to export %s: %v", dbusIface, err)
+	}
+	if err := conn.Export(&dbusProperties{s}, dbusPath, propertiesIface); err != nil {
+		return nil, fmt.Errorf("failed to export properties: %v", err)
+	}
+
+	node := &introspect.Node{
+		Name: string(dbusPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:    dbusIface,
+				Methods: introspect.Methods(s),
+				Signals: []introspect.Signal{
+					{Name: "WorkspaceChanged", Args: []introspect.Arg{{Name: "workspace", Type: "i"}}},
+				},
+				Properties: []introspect.Property{
+					{Name: "CurrentWorkspace", Type: "i", Access: "read"},
+					{Name: "Previous", Type: "i", Access: "read"},
+					{Name: "Brightness", Type: "i", Access: "read"},
+				},
+			},
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), dbusPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		return nil, fmt.Errorf("failed to export introspection data: %v", err)
+	}
+
+	reply, err := conn.RequestName(dbusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request name %s: %v", dbusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("name %s is already taken", dbusName)
+	}
+	return s, nil
+}
+
+func (w *Workspace) serveDBus(o command.Output, d *command.Data) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return o.Err(fmt.Errorf("failed to connect to the session bus: %v", err))
+	}
+	defer conn.Close()
+	s, err := exportDBus(conn, &Client{Workspace: w, Display: displayFlag.Get(d)})
+	if err != nil {
+		return o.Err(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	o.Stdoutf("Serving %s on the session bus\n", dbusName)
+	if err := s.watch(ctx); err != nil {
+		return o.Err(err)
+	}
+	return nil
+}
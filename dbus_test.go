@@ -0,0 +1,239 @@
+package workspace
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// startDBus starts a private session bus and returns its address.
+func startDBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skipf("dbus-daemon is not installed: %v", err)
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address", "--address=unix:dir="+t.TempDir())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to get dbus-daemon stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read dbus-daemon address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+func connectDBus(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatalf("failed to connect to dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestDBus(t *testing.T) {
+	addr := startDBus(t)
+	w := &Workspace{
+		Profiles: map[int]*Profile{
+			1: {Brightness: 60},
+		},
+	}
+	fr := &fakeRunner{responses: map[string][]string{
+		dq: numberedDesktops(4, 1),
+		lm: {"DP-1"},
+	}}
+	s, err := exportDBus(connectDBus(t, addr), &Client{Workspace: w, Runner: fr})
+	if err != nil {
+		t.Fatalf("exportDBus() returned error: %v", err)
+	}
+	// The service's lock must be held when reading the runner and workspace
+	// since the service runs in other goroutines.
+	ran := func() []string {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		got := fr.got
+		fr.got = nil
+		return got
+	}
+
+	conn := connectDBus(t, addr)
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(dbusIface), dbus.WithMatchMember("WorkspaceChanged")); err != nil {
+		t.Fatalf("AddMatchSignal() returned error: %v", err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	obj := conn.Object(dbusName, dbusPath)
+
+	// Properties
+	var props map[string]dbus.Variant
+	if err := obj.Call(propertiesIface+".GetAll", 0, dbusIface).Store(&props); err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+	wantProps := map[string]dbus.Variant{
+		"CurrentWorkspace": dbus.MakeVariant(int32(1)),
+		"Previous":         dbus.MakeVariant(int32(0)),
+		"Brightness":       dbus.MakeVariant(int32(60)),
+	}
+	if diff := cmp.Diff(wantProps, props, cmp.Comparer(func(a, b dbus.Variant) bool { return a.String() == b.String() })); diff != "" {
+		t.Errorf("GetAll returned incorrect properties (-want, +got):\n%s", diff)
+	}
+	if _, err := obj.GetProperty(dbusIface + ".Missing"); err == nil {
+		t.Errorf("GetProperty(Missing) returned nil error; want error")
+	}
+	if err := obj.SetProperty(dbusIface+".Previous", dbus.MakeVariant(int32(2))); err == nil {
+		t.Errorf("SetProperty(Previous) returned nil error; want error")
+	}
+
+	// Methods
+	ran()
+	if err := obj.Call(dbusIface+".Switch", 0, int32(3)).Err; err != nil {
+		t.Fatalf("Switch returned error: %v", err)
+	}
+	if diff := cmp.Diff([]string{dq, lm, "wmctrl -s 3", "xrandr --output DP-1 --brightness 1.00"}, ran()); diff != "" {
+		t.Errorf("Switch ran unexpected commands (-want, +got):\n%s", diff)
+	}
+	select {
+	case sig := <-signals:
+		if diff := cmp.Diff([]interface{}{int32(3)}, sig.Body); diff != "" {
+			t.Errorf("WorkspaceChanged had incorrect body (-want, +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("WorkspaceChanged was not emitted")
+	}
+
+	if err := obj.Call(dbusIface+".SetBrightness", 0, int32(2), int32(80)).Err; err != nil {
+		t.Fatalf("SetBrightness returned error: %v", err)
+	}
+	wantErr := "brightness 300 is not between 5 and 250"
+	if err := obj.Call(dbusIface+".SetBrightness", 0, int32(2), int32(300)).Err; err == nil || err.Error() != wantErr {
+		t.Errorf("SetBrightness(2, 300) returned error %v; want %q", err, wantErr)
+	}
+	wantErr = "workspace 7 does not exist (only 4 workspaces)"
+	if err := obj.Call(dbusIface+".Switch", 0, int32(7)).Err; err == nil || err.Error() != wantErr {
+		t.Errorf("Switch(7) returned error %v; want %q", err, wantErr)
+	}
+
+	want := &Workspace{
+		Prev: intPtr(1),
+		Profiles: map[int]*Profile{
+			1: {Brightness: 60},
+			2: {Brightness: 80},
+		},
+		Journal: &Journal{
+			Undo: []*Settings{{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 60},
+				},
+			}},
+		},
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if diff := cmp.Diff(want, w, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+		t.Errorf("D-Bus service produced unexpected workspace (-want, +got):\n%s", diff)
+	}
+
+	// Only successful switches emit signals.
+	select {
+	case sig := <-signals:
+		t.Errorf("Unexpected signal: %v", sig)
+	default:
+	}
+}
+
+func TestDBusPoll(t *testing.T) {
+	addr := startDBus(t)
+	w := &Workspace{
+		Profiles: map[int]*Profile{
+			2: {Brightness: 40},
+		},
+	}
+	fr := &fakeRunner{responses: map[string][]string{
+		dq: numberedDesktops(4, 1),
+	}}
+	s, err := exportDBus(connectDBus(t, addr), &Client{Workspace: w, Runner: fr})
+	if err != nil {
+		t.Fatalf("exportDBus() returned error: %v", err)
+	}
+
+	conn := connectDBus(t, addr)
+	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbusPath)); err != nil {
+		t.Fatalf("AddMatchSignal() returned error: %v", err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	// The first poll only records the current state.
+	if err := s.poll(); err != nil {
+		t.Fatalf("poll() returned error: %v", err)
+	}
+	// Switch outside of D-Bus.
+	s.mu.Lock()
+	fr.responses[dq] = numberedDesktops(4, 2)
+	s.mu.Unlock()
+	if err := s.poll(); err != nil {
+		t.Fatalf("poll() returned error: %v", err)
+	}
+	// Nothing changed.
+	if err := s.poll(); err != nil {
+		t.Fatalf("poll() returned error: %v", err)
+	}
+
+	var got []*dbus.Signal
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case sig := <-signals:
+			if sig.Name == "org.freedesktop.DBus.NameAcquired" {
+				continue
+			}
+			got = append(got, sig)
+		case <-timeout:
+			t.Fatalf("Got %d signals; want 2", len(got))
+		}
+	}
+	want := []*dbus.Signal{
+		{
+			Path: dbusPath,
+			Name: dbusIface + ".WorkspaceChanged",
+			Body: []interface{}{int32(2)},
+		},
+		{
+			Path: dbusPath,
+			Name: propertiesIface + ".PropertiesChanged",
+			Body: []interface{}{
+				dbusIface,
+				map[string]dbus.Variant{
+					"CurrentWorkspace": dbus.MakeVariant(int32(2)),
+					"Brightness":       dbus.MakeVariant(int32(40)),
+				},
+				[]string{},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(dbus.Signal{}, "Sender", "Sequence"), cmp.Comparer(func(a, b dbus.Variant) bool { return a.String() == b.String() })); diff != "" {
+		t.Errorf("poll() emitted unexpected signals (-want, +got):\n%s", diff)
+	}
+
+	select {
+	case sig := <-signals:
+		t.Errorf("Unexpected signal: %v", sig)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
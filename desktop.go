@@ -0,0 +1,180 @@
+package workspace
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	listDesktops = &command.BashCommand[[]string]{
+		ArgName:  "desktops",
+		Contents: []string{"wmctrl -d"},
+	}
+	listWindows = &command.BashCommand[[]string]{
+		ArgName:  "windows",
+		Contents: []string{"wmctrl -l"},
+	}
+
+	// Example lines:
+	// 0  * DG: 3840x1080  VP: 0,0  WA: 0,0 3840x1052  Workspace 1
+	// 0  * DG: 1920x1080  VP: 0,0  WA: N/A  1
+	// The work area is a single N/A if the window manager doesn't set
+	// _NET_WORKAREA (e.g. i3).
+	desktopRegex = regexp.MustCompile(`^\s*(\d+)\s+([*-])\s+DG:\s*(\S+)\s+VP:\s*(\S+)\s+WA:\s*(?:N/A|(\S+)\s+(\S+))\s+(.*)$`)
+	sizeRegex    = regexp.MustCompile(`^(\d+)x(\d+)$`)
+	pointRegex   = regexp.MustCompile(`^(-?\d+),(-?\d+)$`)
+)
+
+// point is a position in pixels.
+type point struct {
+	x, y int
+}
+
+// size is a width and height in pixels.
+type size struct {
+	width, height int
+}
+
+// desktop is a single desktop as reported by `wmctrl -d`. Fields that the
+// window manager doesn't report (N/A) are nil.
+type desktop struct {
+	index   int
+	current bool
+	// geometry is the size of the whole desktop.
+	geometry *size
+	// viewport is the position of the visible area on the desktop (for
+	// window managers with large desktops, e.g. compiz).
+	viewport *point
+	// workArea is the area of the desktop that isn't covered by panels.
+	workAreaPos  *point
+	workAreaSize *size
+	name         string
+}
+
+func parseSize(s string) (*size, error) {
+	if s == "N/A" {
+		return nil, nil
+	}
+	m := sizeRegex.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid size %q", s)
+	}
+	w, _ := strconv.Atoi(m[1])
+	h, _ := strconv.Atoi(m[2])
+	return &size{w, h}, nil
+}
+
+func parsePoint(s string) (*point, error) {
+	if s == "N/A" {
+		return nil, nil
+	}
+	m := pointRegex.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid position %q", s)
+	}
+	x, _ := strconv.Atoi(m[1])
+	y, _ := strconv.Atoi(m[2])
+	return &point{x, y}, nil
+}
+
+func parseDesktops(lines []string) ([]*desktop, error) {
+	var ds []*desktop
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := desktopRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("failed to parse wmctrl desktop line: %q", line)
+		}
+		idx, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse desktop index: %v", err)
+		}
+		d := &desktop{
+			index:   idx,
+			current: m[2] == "*",
+			name:    strings.TrimSpace(m[7]),
+		}
+		if d.geometry, err = parseSize(m[3]); err != nil {
+			return nil, fmt.Errorf("failed to parse desktop geometry in %q: %v", line, err)
+		}
+		if d.viewport, err = parsePoint(m[4]); err != nil {
+			return nil, fmt.Errorf("failed to parse desktop viewport in %q: %v", line, err)
+		}
+		if m[5] != "" {
+			if d.workAreaPos, err = parsePoint(m[5]); err != nil {
+				return nil, fmt.Errorf("failed to parse desktop work area in %q: %v", line, err)
+			}
+			if d.workAreaSize, err = parseSize(m[6]); err != nil {
+				return nil, fmt.Errorf("failed to parse desktop work area in %q: %v", line, err)
+			}
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+// countDesktops returns the number of desktops.
+func countDesktops(ds []*desktop) (int, error) {
+	if len(ds) == 0 {
+		return 0, ErrNoWorkspaces
+	}
+	return len(ds), nil
+}
+
+// currentDesktop returns the index of the current desktop.
+func currentDesktop(ds []*desktop) (int, error) {
+	for _, d := range ds {
+		if d.current {
+			return d.index, nil
+		}
+	}
+	return 0, ErrNoCurrentWorkspace
+}
+
+// window is a single window as reported by `wmctrl -l`.
+type window struct {
+	id string
+	// desktop is the desktop the window is on (-1 for sticky windows).
+	desktop int
+}
+
+func parseWindows(lines []string) ([]*window, error) {
+	var ws []*window
+	for _, line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("failed to parse wmctrl window line: %q", line)
+		}
+		idx, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse window desktop: %v", err)
+		}
+		ws = append(ws, &window{parts[0], idx})
+	}
+	return ws, nil
+}
+
+// parseWindowCounts returns the number of windows on each desktop from the
+// output of `wmctrl -l`. Sticky windows (desktop -1) are ignored.
+func parseWindowCounts(lines []string) (map[int]int, error) {
+	ws, err := parseWindows(lines)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[int]int{}
+	for _, w := range ws {
+		if w.desktop >= 0 {
+			counts[w.desktop]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,173 @@
+package workspace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseDesktops(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		lines   []string
+		want    []*desktop
+		wantErr error
+	}{
+		{
+			name: "handles no desktops",
+		},
+		{
+			name: "parses desktops",
+			lines: []string{
+				"0  - DG: 3840x1080  VP: 0,0  WA: 0,0 3840x1052  main",
+				"",
+				"1  * DG: 3840x1080  VP: N/A  WA: 0,27 1920x1053  my web",
+				"12 - DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  N/A",
+				"13 - DG: N/A  VP: N/A  WA: N/A  unmanaged",
+				"14 - DG: 5760x1080  VP: -1920,0  WA: 0,0 5760x1080  compiz",
+			},
+			want: []*desktop{
+				{
+					index:        0,
+					name:         "main",
+					geometry:     &size{3840, 1080},
+					viewport:     &point{0, 0},
+					workAreaPos:  &point{0, 0},
+					workAreaSize: &size{3840, 1052},
+				},
+				{
+					index:        1,
+					current:      true,
+					name:         "my web",
+					geometry:     &size{3840, 1080},
+					workAreaPos:  &point{0, 27},
+					workAreaSize: &size{1920, 1053},
+				},
+				{
+					index:        12,
+					name:         "N/A",
+					geometry:     &size{1920, 1080},
+					viewport:     &point{0, 0},
+					workAreaPos:  &point{0, 0},
+					workAreaSize: &size{1920, 1080},
+				},
+				{
+					index: 13,
+					name:  "unmanaged",
+				},
+				{
+					index:        14,
+					name:         "compiz",
+					geometry:     &size{5760, 1080},
+					viewport:     &point{-1920, 0},
+					workAreaPos:  &point{0, 0},
+					workAreaSize: &size{5760, 1080},
+				},
+			},
+		},
+		{
+			name: "parses desktops without work area",
+			lines: []string{
+				"0  * DG: 1920x1080  VP: 0,0  WA: N/A  1",
+				"1  - DG: 1920x1080  VP: 0,0  WA: N/A  2: web",
+			},
+			want: []*desktop{
+				{
+					index:    0,
+					current:  true,
+					name:     "1",
+					geometry: &size{1920, 1080},
+					viewport: &point{0, 0},
+				},
+				{
+					index:    1,
+					name:     "2: web",
+					geometry: &size{1920, 1080},
+					viewport: &point{0, 0},
+				},
+			},
+		},
+		{
+			name:    "fails on invalid geometry",
+			lines:   []string{"0  * DG: wide  VP: 0,0  WA: 0,0 3840x1052  main"},
+			wantErr: fmt.Errorf(`failed to parse desktop geometry in "0  * DG: wide  VP: 0,0  WA: 0,0 3840x1052  main": invalid size "wide"`),
+		},
+		{
+			name:    "fails on invalid work area",
+			lines:   []string{"0  * DG: 3840x1080  VP: 0,0  WA: 0;0 3840x1052  main"},
+			wantErr: fmt.Errorf(`failed to parse desktop work area in "0  * DG: 3840x1080  VP: 0,0  WA: 0;0 3840x1052  main": invalid position "0;0"`),
+		},
+		{
+			name:    "fails on unknown format",
+			lines:   []string{"0  * main"},
+			wantErr: fmt.Errorf(`failed to parse wmctrl desktop line: "0  * main"`),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseDesktops(test.lines)
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("parseDesktops(%v) returned unexpected error (-want, +got):\n%s", test.lines, diff)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(desktop{}, point{}, size{})); diff != "" {
+				t.Errorf("parseDesktops(%v) returned unexpected desktops (-want, +got):\n%s", test.lines, diff)
+			}
+		})
+	}
+}
+
+func TestParseWindowCounts(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		lines   []string
+		want    map[int]int
+		wantErr error
+	}{
+		{
+			name: "handles no windows",
+			want: map[int]int{},
+		},
+		{
+			name: "counts windows",
+			lines: []string{
+				"0x03a00003  0 host Terminal",
+				"0x03a00004  0 host Other terminal",
+				"",
+				"0x04200001 -1 host Panel",
+				"0x05000007  3 host Browser",
+			},
+			want: map[int]int{
+				0: 2,
+				3: 1,
+			},
+		},
+		{
+			name:    "fails on short line",
+			lines:   []string{"0x03a00003"},
+			wantErr: fmt.Errorf(`failed to parse wmctrl window line: "0x03a00003"`),
+		},
+		{
+			name:    "fails on invalid desktop",
+			lines:   []string{"0x03a00003 host Terminal"},
+			wantErr: fmt.Errorf(`failed to parse window desktop: strconv.Atoi: parsing "host": invalid syntax`),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseWindowCounts(test.lines)
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("parseWindowCounts(%v) returned unexpected error (-want, +got):\n%s", test.lines, diff)
+			}
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("parseWindowCounts(%v) returned unexpected counts (-want, +got):\n%s", test.lines, diff)
+			}
+		})
+	}
+}
+
+var cmpErr = cmp.Comparer(func(this, that error) bool {
+	if this == nil || that == nil {
+		return this == nil && that == nil
+	}
+	return this.Error() == that.Error()
+})
@@ -0,0 +1,63 @@
+package workspace
+
+import (
+	"os"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	displayFlag = command.Flag[string]("display", 'd', "X display to use instead of $DISPLAY (e.g. :1)", command.MatchesRegex(`^[A-Za-z0-9.\-]*:[0-9]+(\.[0-9]+)?$`))
+
+	// Stubbed out for tests.
+	setenv = os.Setenv
+
+	// useDisplay points all of the bash commands that run after it at the
+	// display from the display flag (if provided).
+	useDisplay = command.SuperSimpleProcessor(func(i *command.Input, d *command.Data) error {
+		if !displayFlag.Provided(d) {
+			return nil
+		}
+		return setenv("DISPLAY", displayFlag.Get(d))
+	})
+)
+
+// forDisplay returns the state for the provided display. The Workspace
+// itself is the state for the default display (i.e. $DISPLAY).
+func (w *Workspace) forDisplay(display string) *Workspace {
+	if display == "" {
+		return w
+	}
+	if w.Displays == nil {
+		w.Displays = map[string]*Workspace{}
+	}
+	if w.Displays[display] == nil {
+		w.Displays[display] = &Workspace{}
+	}
+	return w.Displays[display]
+}
+
+// onDisplay updates the executables to run against the provided display.
+// The executables are run in the user's shell, so DISPLAY is set for each
+// command rather than exported.
+func onDisplay(display string, executables []string) []string {
+	if display == "" {
+		return executables
+	}
+	var r []string
+	for _, e := range executables {
+		r = append(r, "DISPLAY="+display+" "+e)
+	}
+	return r
+}
+
+// apply runs f against the state for the provided display.
+func (w *Workspace) apply(display string, f workspaceFunc, o command.Output, d *command.Data) ([]string, error) {
+	t := w.forDisplay(display)
+	t.switched = nil
+	r, err := f(t, o, d)
+	if t.changed {
+		w.changed = true
+	}
+	return r, err
+}
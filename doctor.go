@@ -0,0 +1,165 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	// Stubbed out for tests.
+	lookPath = exec.LookPath
+	getenv   = os.Getenv
+)
+
+// diagnosis is the result of a single doctor check.
+type diagnosis struct {
+	check string
+	// skip is the reason the check wasn't run (if any).
+	skip     string
+	problems []string
+	fixes    []string
+}
+
+func (dg *diagnosis) fail(problem, fix string) {
+	dg.problems = append(dg.problems, problem)
+	dg.fixes = append(dg.fixes, fix)
+}
+
+func (dg *diagnosis) print(o command.Output) {
+	if dg.skip != "" {
+		o.Stdoutf("[skip] %s (%s)\n", dg.check, dg.skip)
+		return
+	}
+	if len(dg.problems) == 0 {
+		o.Stdoutf("[ok]   %s\n", dg.check)
+		return
+	}
+	o.Stdoutf("[fail] %s\n", dg.check)
+	for i, p := range dg.problems {
+		o.Stdoutf("         problem: %s\n", p)
+		o.Stdoutf("         fix:     %s\n", dg.fixes[i])
+	}
+}
+
+func (w *Workspace) doctor(o command.Output, d *command.Data) error {
+	var dgs []*diagnosis
+	// Check the saved state rather than the copy that sourcerer loaded.
+	state, stateErr := w.savedState(displayFlag.Get(d))
+
+	wmctrl := &diagnosis{check: "wmctrl is installed"}
+	if _, err := lookPath("wmctrl"); err != nil {
+		wmctrl.fail(err.Error(), "install wmctrl (e.g. `sudo apt install wmctrl`)")
+	}
+	xrandr := &diagnosis{check: "xrandr is installed"}
+	if _, err := lookPath("xrandr"); err != nil {
+		xrandr.fail(err.Error(), "install xrandr (e.g. `sudo apt install x11-xserver-utils`)")
+	}
+	display := &diagnosis{check: "a display is set"}
+	if getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+		display.fail("neither DISPLAY nor WAYLAND_DISPLAY is set", "run ws from a graphical session or export the display to use (e.g. `export DISPLAY=:0`)")
+	}
+	dgs = append(dgs, wmctrl, xrandr, display)
+	if state != nil && state.PerMonitor {
+		i3 := &diagnosis{check: "i3-msg is installed (for per-monitor workspaces)"}
+		if _, err := lookPath("i3-msg"); err != nil {
+			i3.fail(err.Error(), "use i3, or run `ws per-monitor false`")
+		}
+		dgs = append(dgs, i3)
+	}
+
+	ewmh := &diagnosis{check: "window manager supports EWMH desktops"}
+	numDesktops := -1
+	if len(wmctrl.problems) > 0 {
+		ewmh.skip = "wmctrl is not installed"
+	} else if lines, err := listDesktops.Run(o, d); err != nil {
+		ewmh.fail(fmt.Sprintf("`wmctrl -d` failed: %v", err), "make sure the display is reachable and the window manager is running")
+	} else if desktops, err := parseDesktops(lines); err != nil {
+		ewmh.fail(err.Error(), "make sure the installed wmctrl version outputs the standard `wmctrl -d` format")
+	} else if len(desktops) == 0 {
+		ewmh.fail("the window manager reports no desktops", "use a window manager that sets _NET_NUMBER_OF_DESKTOPS and _NET_CURRENT_DESKTOP")
+	} else {
+		numDesktops = len(desktops)
+	}
+	dgs = append(dgs, ewmh)
+
+	outputs := &diagnosis{check: "xrandr reports a connected output"}
+	if len(xrandr.problems) > 0 {
+		outputs.skip = "xrandr is not installed"
+	} else if mcs, err := listMcs.Run(o, d); err != nil {
+		outputs.fail(fmt.Sprintf("`xrandr --query` failed: %v", err), "make sure the display is reachable and uses the X RandR extension")
+	} else if len(mcs) == 0 {
+		outputs.fail("no connected outputs found", "check that a monitor is connected with `xrandr --query`")
+	}
+	dgs = append(dgs, outputs)
+
+	if stateErr != nil {
+		dg := &diagnosis{check: "saved state is valid"}
+		dg.fail(stateErr.Error(), "remove the saved ws state so it can be recreated")
+		dgs = append(dgs, dg)
+	} else {
+		dgs = append(dgs, state.diagnoseState(numDesktops))
+	}
+
+	var failed int
+	for _, dg := range dgs {
+		dg.print(o)
+		if len(dg.problems) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return o.Stderrln(fmt.Sprintf("%d check(s) failed", failed))
+	}
+	return nil
+}
+
+// savedState loads the stored state for the provided display. The
+// in-memory state is used if there is no store or nothing is saved yet.
+func (w *Workspace) savedState(display string) (*Workspace, error) {
+	s := w
+	if w.store != nil {
+		ss, ok, err := w.store.load()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			s = ss.Workspace
+		}
+	}
+	if display == "" {
+		return s, nil
+	}
+	// Don't use forDisplay since doctor must not change the state.
+	if t := s.Displays[display]; t != nil {
+		return t, nil
+	}
+	return &Workspace{}, nil
+}
+
+// diagnoseState checks that the saved state is within range. numDesktops is
+// ignored if negative.
+func (w *Workspace) diagnoseState(numDesktops int) *diagnosis {
+	dg := &diagnosis{check: "saved state is valid"}
+	if p := w.Prev; p != nil && (*p < 0 || (numDesktops >= 0 && *p >= numDesktops)) {
+		dg.fail(fmt.Sprintf("previous workspace %d does not exist", *p), "move to any workspace (e.g. `ws 0`) to reset it")
+	}
+
+	var outOfRange []string
+	for _, k := range w.configuredBrightness() {
+		b := w.Profiles[k].Brightness
+		if b < minBrightness || b > maxBrightness {
+			dg.fail(fmt.Sprintf("brightness %d for workspace %d is not between %d and %d", b, k, minBrightness, maxBrightness), fmt.Sprintf("run `ws brightness set %d %d`", k, defaultBrightness))
+		}
+		if k < 0 || (numDesktops >= 0 && k >= numDesktops) {
+			outOfRange = append(outOfRange, fmt.Sprintf("%d", k))
+		}
+	}
+	if len(outOfRange) > 0 {
+		dg.fail(fmt.Sprintf("brightness is set for nonexistent workspace(s): %s", strings.Join(outOfRange, ", ")), "add more desktops in your window manager or ignore this if it is intentional")
+	}
+	return dg
+}
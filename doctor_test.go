@@ -0,0 +1,201 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/leep-frog/command"
+)
+
+func TestDoctor(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		w       *Workspace
+		missing []string
+		env     map[string]string
+		etc     *command.ExecuteTestCase
+	}{
+		{
+			name: "all checks pass",
+			w: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 40},
+				},
+			},
+			env: map[string]string{"DISPLAY": ":0"},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(0, "main", "web"), mcRun("DP-1")},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantStdout: strings.Join([]string{
+					"[ok]   wmctrl is installed",
+					"[ok]   xrandr is installed",
+					"[ok]   a display is set",
+					"[ok]   window manager supports EWMH desktops",
+					"[ok]   xrandr reports a connected output",
+					"[ok]   saved state is valid",
+					"",
+				}, "\n"),
+			},
+		},
+		{
+			name:    "skips checks when tools are missing",
+			missing: []string{"wmctrl", "xrandr"},
+			env:     map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+			etc: &command.ExecuteTestCase{
+				WantStdout: strings.Join([]string{
+					"[fail] wmctrl is installed",
+					"         problem: wmctrl not found",
+					"         fix:     install wmctrl (e.g. `sudo apt install wmctrl`)",
+					"[fail] xrandr is installed",
+					"         problem: xrandr not found",
+					"         fix:     install xrandr (e.g. `sudo apt install x11-xserver-utils`)",
+					"[ok]   a display is set",
+					"[skip] window manager supports EWMH desktops (wmctrl is not installed)",
+					"[skip] xrandr reports a connected output (xrandr is not installed)",
+					"[ok]   saved state is valid",
+					"",
+				}, "\n"),
+				WantStderr: "2 check(s) failed\n",
+				WantErr:    fmt.Errorf("2 check(s) failed"),
+			},
+		},
+		{
+			name: "reports display, desktop, output, and state problems",
+			w: &Workspace{
+				Prev: intPtr(4),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 300},
+					7: {Brightness: 50},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(0, "main", "web"), mcRun()},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantStdout: strings.Join([]string{
+					"[ok]   wmctrl is installed",
+					"[ok]   xrandr is installed",
+					"[fail] a display is set",
+					"         problem: neither DISPLAY nor WAYLAND_DISPLAY is set",
+					"         fix:     run ws from a graphical session or export the display to use (e.g. `export DISPLAY=:0`)",
+					"[ok]   window manager supports EWMH desktops",
+					"[fail] xrandr reports a connected output",
+					"         problem: no connected outputs found",
+					"         fix:     check that a monitor is connected with `xrandr --query`",
+					"[fail] saved state is valid",
+					"         problem: previous workspace 4 does not exist",
+					"         fix:     move to any workspace (e.g. `ws 0`) to reset it",
+					"         problem: brightness 300 for workspace 1 is not between 5 and 250",
+					"         fix:     run `ws brightness set 1 100`",
+					"         problem: brightness is set for nonexistent workspace(s): 7",
+					"         fix:     add more desktops in your window manager or ignore this if it is intentional",
+					"",
+				}, "\n"),
+				WantStderr: "3 check(s) failed\n",
+				WantErr:    fmt.Errorf("3 check(s) failed"),
+			},
+		},
+		{
+			name: "reports wmctrl failure",
+			env:  map[string]string{"DISPLAY": ":1"},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{errRun("Cannot open display."), mcRun("eDP-1")},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantStdout: strings.Join([]string{
+					"[ok]   wmctrl is installed",
+					"[ok]   xrandr is installed",
+					"[ok]   a display is set",
+					"[fail] window manager supports EWMH desktops",
+					"         problem: `wmctrl -d` failed: failed to execute bash command: Cannot open display.",
+					"         fix:     make sure the display is reachable and the window manager is running",
+					"[ok]   xrandr reports a connected output",
+					"[ok]   saved state is valid",
+					"",
+				}, "\n"),
+				WantStderr: "1 check(s) failed\n",
+				WantErr:    fmt.Errorf("1 check(s) failed"),
+			},
+		},
+		{
+			name: "checks the state for the display",
+			w: &Workspace{
+				Displays: map[string]*Workspace{
+					":1": {
+						Prev:       intPtr(3),
+						PerMonitor: true,
+					},
+				},
+			},
+			env: map[string]string{"DISPLAY": ":0"},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"doctor", "-d", ":1"},
+				RunResponses:    []*command.FakeRun{desktopRun(0, "main", "web"), mcRun("DP-1")},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantStdout: strings.Join([]string{
+					"[ok]   wmctrl is installed",
+					"[ok]   xrandr is installed",
+					"[ok]   a display is set",
+					"[ok]   i3-msg is installed (for per-monitor workspaces)",
+					"[ok]   window manager supports EWMH desktops",
+					"[ok]   xrandr reports a connected output",
+					"[fail] saved state is valid",
+					"         problem: previous workspace 3 does not exist",
+					"         fix:     move to any workspace (e.g. `ws 0`) to reset it",
+					"",
+				}, "\n"),
+				WantStderr: "1 check(s) failed\n",
+				WantErr:    fmt.Errorf("1 check(s) failed"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						displayFlag.Name(): ":1",
+					},
+				},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldLookPath, oldGetenv := lookPath, getenv
+			defer func() { lookPath, getenv = oldLookPath, oldGetenv }()
+			lookPath = func(file string) (string, error) {
+				for _, m := range test.missing {
+					if m == file {
+						return "", fmt.Errorf("%s not found", file)
+					}
+				}
+				return "/usr/bin/" + file, nil
+			}
+			getenv = func(key string) string {
+				return test.env[key]
+			}
+
+			w := test.w
+			if w == nil {
+				w = &Workspace{}
+			}
+			test.etc.Node = w.Node()
+			if test.etc.Args == nil {
+				test.etc.Args = []string{"doctor"}
+			}
+			command.ExecuteTest(t, test.etc)
+			command.ChangeTest(t, nil, w, cmpopts.IgnoreUnexported(Workspace{}))
+		})
+	}
+}
+
+func TestDoctorReportsUnreadableState(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(p, []byte(`{"Workspace": {"Version": 99}}`), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	w := &Workspace{store: &store{p}}
+	_, err := w.savedState("")
+	want := fmt.Errorf("failed to parse state: unsupported state version 99 (latest supported version is %d)", stateVersion)
+	if diff := cmp.Diff(want, err, cmpErr); diff != "" {
+		t.Errorf("savedState() returned unexpected error (-want, +got):\n%s", diff)
+	}
+}
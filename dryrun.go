@@ -0,0 +1,159 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	dryRunFlag = command.BoolFlag("dry-run", 'n', "Print what would be executed and saved without doing either")
+)
+
+// workspaceFunc is a function that operates on (and possibly modifies) a Workspace.
+type workspaceFunc func(*Workspace, command.Output, *command.Data) ([]string, error)
+
+// executable returns a processor that runs f against the workspace and
+// outputs any error that f returns. If the dry-run flag is set, f is run
+// against a copy of the workspace instead and the resulting executables and
+// state changes are printed.
+func (w *Workspace) executable(f workspaceFunc) command.Processor {
+	return command.ExecutableProcessor(func(o command.Output, d *command.Data) ([]string, error) {
+		display := displayFlag.Get(d)
+		if !dryRunFlag.Get(d) {
+			r, err := w.transaction(display, f, o, d)
+			if err != nil {
+				return nil, o.Err(err)
+			}
+			return onDisplay(display, r), nil
+		}
+		if err := w.reload(); err != nil {
+			return nil, o.Err(err)
+		}
+		t := w.forDisplay(display)
+		c, err := t.clone()
+		if err != nil {
+			return nil, o.Err(err)
+		}
+		r, err := f(c, o, d)
+		if err != nil {
+			return nil, o.Err(err)
+		}
+		return nil, dryRunReport(o, t, c, onDisplay(display, r))
+	})
+}
+
+// executor is the same as executable, but for functions that only change
+// settings. The changes are journaled so they can be undone.
+func (w *Workspace) executor(f func(*Workspace, command.Output, *command.Data) error) command.Processor {
+	return w.executable(journaled(func(w *Workspace, o command.Output, d *command.Data) ([]string, error) {
+		return nil, f(w, o, d)
+	}))
+}
+
+// clone returns a deep copy of the persisted fields of the workspace.
+func (w *Workspace) clone() (*Workspace, error) {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy workspace: %v", err)
+	}
+	c := &Workspace{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("failed to copy workspace: %v", err)
+	}
+	return c, nil
+}
+
+func dryRunReport(o command.Output, before, after *Workspace, executables []string) error {
+	if len(executables) == 0 {
+		o.Stdoutln("Would not run anything")
+	} else {
+		o.Stdoutln("Would run:")
+		for _, e := range executables {
+			o.Stdoutf("  %s\n", e)
+		}
+	}
+
+	bf, err := flattenState(before)
+	if err != nil {
+		return o.Err(err)
+	}
+	af, err := flattenState(after)
+	if err != nil {
+		return o.Err(err)
+	}
+	keys := map[string]bool{}
+	for k := range bf {
+		keys[k] = true
+	}
+	for k := range af {
+		keys[k] = true
+	}
+	var changes []string
+	for k := range keys {
+		// The journal only records the changes, so it isn't interesting.
+		if strings.HasPrefix(k, "Journal.") {
+			continue
+		}
+		if bf[k] != af[k] {
+			changes = append(changes, k)
+		}
+	}
+	sort.Strings(changes)
+
+	if len(changes) == 0 {
+		o.Stdoutln("Would not change any saved state")
+		return nil
+	}
+	o.Stdoutln("Would change:")
+	for _, k := range changes {
+		o.Stdoutf("  %s: %s -> %s\n", k, orUnset(bf[k]), orUnset(af[k]))
+	}
+	return nil
+}
+
+func orUnset(s string) string {
+	if s == "" {
+		return "<unset>"
+	}
+	return s
+}
+
+// flattenState converts the persisted fields of a workspace into a map from
+// field path (e.g. "Profiles.3.Brightness") to value.
+func flattenState(w *Workspace) (map[string]string, error) {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workspace: %v", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workspace: %v", err)
+	}
+	m := map[string]string{}
+	flatten("", v, m)
+	return m, nil
+}
+
+func flatten(prefix string, v interface{}, m map[string]string) {
+	switch t := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, sv := range t {
+			if prefix == "" {
+				flatten(k, sv, m)
+			} else {
+				flatten(prefix+"."+k, sv, m)
+			}
+		}
+	case []interface{}:
+		for i, sv := range t {
+			flatten(fmt.Sprintf("%s.%d", prefix, i), sv, m)
+		}
+	default:
+		m[prefix] = fmt.Sprintf("%v", t)
+	}
+}
@@ -0,0 +1,101 @@
+package workspace
+
+import (
+	"github.com/leep-frog/command"
+)
+
+const (
+	// currentWorkspaceKey is set in the data to override the current
+	// workspace reported by the window manager (e.g. while a switch is
+	// still pending).
+	currentWorkspaceKey = "currentWorkspace"
+)
+
+// env provides information about the desktop environment.
+type env interface {
+	// desktops returns the parsed output of `wmctrl -d`.
+	desktops() ([]*desktop, error)
+	numWorkspaces() (int, error)
+	currentWorkspace() (int, error)
+	// monitors returns the connected monitor codes.
+	monitors() ([]string, error)
+	// windows returns the output of `wmctrl -l`.
+	windows() ([]string, error)
+	// outputWorkspaces returns the output of `i3-msg -t get_workspaces`.
+	outputWorkspaces() ([]string, error)
+	// warn reports a problem that doesn't prevent the operation from
+	// completing.
+	warn(err error, msg string)
+}
+
+// cliEnv is the env used by the CLI. Values that were populated by the
+// node's bash commands are read from the data; all others are run when
+// requested.
+type cliEnv struct {
+	o command.Output
+	d *command.Data
+	// ds is the parsed desktops, once they have been requested.
+	ds []*desktop
+}
+
+func newCLIEnv(o command.Output, d *command.Data) *cliEnv {
+	return &cliEnv{o: o, d: d}
+}
+
+func getOrRun[T any](bc *command.BashCommand[T], o command.Output, d *command.Data) (T, error) {
+	if d.Has(bc.ArgName) {
+		return bc.Get(d), nil
+	}
+	return bc.Run(o, d)
+}
+
+func (e *cliEnv) desktops() ([]*desktop, error) {
+	if e.ds != nil {
+		return e.ds, nil
+	}
+	lines, err := getOrRun(listDesktops, e.o, e.d)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := parseDesktops(lines)
+	if err != nil {
+		return nil, err
+	}
+	e.ds = ds
+	return ds, nil
+}
+
+func (e *cliEnv) numWorkspaces() (int, error) {
+	ds, err := e.desktops()
+	if err != nil {
+		return 0, err
+	}
+	return countDesktops(ds)
+}
+
+func (e *cliEnv) currentWorkspace() (int, error) {
+	if e.d.Has(currentWorkspaceKey) {
+		return e.d.Int(currentWorkspaceKey), nil
+	}
+	ds, err := e.desktops()
+	if err != nil {
+		return 0, err
+	}
+	return currentDesktop(ds)
+}
+
+func (e *cliEnv) monitors() ([]string, error) {
+	return getOrRun(listMcs, e.o, e.d)
+}
+
+func (e *cliEnv) windows() ([]string, error) {
+	return getOrRun(listWindows, e.o, e.d)
+}
+
+func (e *cliEnv) outputWorkspaces() ([]string, error) {
+	return getOrRun(listOutputWorkspaces, e.o, e.d)
+}
+
+func (e *cliEnv) warn(err error, msg string) {
+	e.o.Annotate(err, msg)
+}
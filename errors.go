@@ -0,0 +1,55 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoWorkspaces is returned when the number of workspaces can't be
+	// determined (or is zero).
+	ErrNoWorkspaces = errors.New("couldn't get number of workspaces")
+	// ErrNoCurrentWorkspace is returned when no workspace is marked as the
+	// current one.
+	ErrNoCurrentWorkspace = errors.New("couldn't get current workspace")
+)
+
+// WorkspaceNotFoundError is returned when a workspace index is out of range.
+type WorkspaceNotFoundError struct {
+	Workspace     int
+	NumWorkspaces int
+	// Spec is the workspace as it was specified (e.g. "$-3") if it was
+	// counted from the end rather than given as an index.
+	Spec string
+}
+
+func (e *WorkspaceNotFoundError) Error() string {
+	if e.Spec != "" {
+		return fmt.Sprintf("workspace %q does not exist (only %d workspaces)", e.Spec, e.NumWorkspaces)
+	}
+	return fmt.Sprintf("workspace %d does not exist (only %d workspaces)", e.Workspace, e.NumWorkspaces)
+}
+
+// BrightnessRangeError is returned when a brightness is outside of the
+// supported range.
+type BrightnessRangeError struct {
+	Brightness int
+}
+
+func (e *BrightnessRangeError) Error() string {
+	return fmt.Sprintf("brightness %d is not between %d and %d", e.Brightness, minBrightness, maxBrightness)
+}
+
+// CommandError is returned when a query or command fails to run.
+type CommandError struct {
+	Command string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("failed to run %q: %v", e.Command, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
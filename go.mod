@@ -3,6 +3,7 @@ module github.com/leep-frog/workspace
 go 1.18
 
 require (
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/google/go-cmp v0.5.5
 	github.com/leep-frog/command v0.0.0-20230201152427-33dee6ca6e87
 )
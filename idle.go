@@ -0,0 +1,117 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	idlePollInterval = 2 * time.Second
+)
+
+var (
+	idleAfterFlag = command.Flag[int]("after", 'a', "Minutes of no input before dimming", command.Default(5), command.Positive[int]())
+	idleDimFlag   = command.Flag[int]("dim", 'p', "Percentage of the workspace's brightness to dim to", command.Default(30), command.GTE(1), command.LTE(100))
+
+	// xprintidle prints the number of milliseconds since the last input.
+	xprintidle = &command.BashCommand[int]{
+		ArgName:  "idleMs",
+		Contents: []string{"xprintidle"},
+	}
+)
+
+// idleDimmer dims the monitors when there has been no input for a while, and
+// restores the current workspace's brightness when there is input again.
+type idleDimmer struct {
+	client *Client
+	// after is how long to wait after the last input before dimming.
+	after time.Duration
+	// percent is the percentage of the workspace's brightness to dim to.
+	percent int
+	dimmed  bool
+}
+
+// poll checks the idle time once and dims or restores the monitors if
+// necessary.
+func (dm *idleDimmer) poll(ctx context.Context) error {
+	e := dm.client.env(ctx)
+	ms, err := e.queryInt(xprintidle.Contents)
+	if err != nil {
+		return err
+	}
+	idle := time.Duration(ms)*time.Millisecond >= dm.after
+	if idle == dm.dimmed {
+		return nil
+	}
+	if err := dm.apply(e, idle); err != nil {
+		return err
+	}
+	dm.dimmed = idle
+	return nil
+}
+
+// apply sets the monitors to the current workspace's brightness (or the
+// brightness of the focused window's rule, if one is applied), or the dimmed
+// brightness if dim is true.
+func (dm *idleDimmer) apply(e *runnerEnv, dim bool) error {
+	// Always use the latest saved brightness.
+	if err := dm.client.Workspace.reload(); err != nil {
+		return err
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return err
+	}
+	mcs, err := e.monitors()
+	if err != nil {
+		return err
+	}
+	s := dm.client.state()
+	b := s.brightness(c)
+	if s.RuleBrightness != nil {
+		b = *s.RuleBrightness
+	}
+	if dim {
+		if b = b * dm.percent / 100; b < minBrightness {
+			b = minBrightness
+		}
+	}
+	return e.runAll(s.setBrightness(mcs, b))
+}
+
+// run polls until ctx is done, and then restores the brightness if the
+// monitors are dimmed.
+func (dm *idleDimmer) run(ctx context.Context) error {
+	for {
+		if err := dm.poll(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			if !dm.dimmed {
+				return nil
+			}
+			return dm.apply(dm.client.env(context.Background()), false)
+		case <-time.After(idlePollInterval):
+		}
+	}
+}
+
+func (w *Workspace) idle(o command.Output, d *command.Data) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	dm := &idleDimmer{
+		client:  &Client{Workspace: w, Display: displayFlag.Get(d)},
+		after:   time.Duration(idleAfterFlag.Get(d)) * time.Minute,
+		percent: idleDimFlag.Get(d),
+	}
+	if err := dm.run(ctx); err != nil {
+		return o.Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdleDimmer(t *testing.T) {
+	xi := query(xprintidle)
+
+	w := &Workspace{
+		Profiles: map[int]*Profile{
+			1: {Brightness: 80},
+			2: {Brightness: 10},
+		},
+	}
+	fr := &fakeRunner{
+		responses: map[string][]string{
+			dq: numberedDesktops(4, 1),
+			lm: {"DP-1", "eDP-1"},
+		},
+		errs: map[string]error{},
+	}
+	dm := &idleDimmer{
+		client:  &Client{Workspace: w, Runner: fr},
+		after:   5 * time.Minute,
+		percent: 25,
+	}
+
+	for _, step := range []struct {
+		name    string
+		idleMs  string
+		current int
+		idleErr error
+		// rule is the brightness of the applied brightness rule, if any.
+		rule       *int
+		wantRun    []string
+		wantErr    error
+		wantDimmed bool
+	}{
+		{
+			name:    "does nothing when active",
+			idleMs:  "1000",
+			current: 1,
+			wantRun: []string{xi},
+		},
+		{
+			name:    "dims after idle time",
+			idleMs:  "300000",
+			current: 1,
+			wantRun: []string{
+				xi,
+				dq,
+				lm,
+				"xrandr --output DP-1 --brightness 0.20",
+				"xrandr --output eDP-1 --brightness 0.20",
+			},
+			wantDimmed: true,
+		},
+		{
+			name:       "does nothing when still idle",
+			idleMs:     "400000",
+			current:    1,
+			wantRun:    []string{xi},
+			wantDimmed: true,
+		},
+		{
+			name:    "restores brightness on input",
+			idleMs:  "20",
+			current: 1,
+			wantRun: []string{
+				xi,
+				dq,
+				lm,
+				"xrandr --output DP-1 --brightness 0.80",
+				"xrandr --output eDP-1 --brightness 0.80",
+			},
+		},
+		{
+			name:    "dims rule brightness",
+			idleMs:  "300000",
+			current: 2,
+			rule:    intPtr(80),
+			wantRun: []string{
+				xi,
+				dq,
+				lm,
+				"xrandr --output DP-1 --brightness 0.20",
+				"xrandr --output eDP-1 --brightness 0.20",
+			},
+			wantDimmed: true,
+		},
+		{
+			name:    "restores rule brightness on input",
+			idleMs:  "20",
+			current: 2,
+			rule:    intPtr(80),
+			wantRun: []string{
+				xi,
+				dq,
+				lm,
+				"xrandr --output DP-1 --brightness 0.80",
+				"xrandr --output eDP-1 --brightness 0.80",
+			},
+		},
+		{
+			name:    "dims to the minimum brightness",
+			idleMs:  "300000",
+			current: 2,
+			wantRun: []string{
+				xi,
+				dq,
+				lm,
+				fmt.Sprintf("xrandr --output DP-1 --brightness %0.2f", float64(minBrightness)/100),
+				fmt.Sprintf("xrandr --output eDP-1 --brightness %0.2f", float64(minBrightness)/100),
+			},
+			wantDimmed: true,
+		},
+		{
+			name:       "fails if idle time can't be determined",
+			current:    2,
+			idleErr:    fmt.Errorf("no X server"),
+			wantRun:    []string{xi},
+			wantErr:    &CommandError{xi, fmt.Errorf("no X server")},
+			wantDimmed: true,
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			fr.got = nil
+			fr.responses[xi] = []string{step.idleMs}
+			fr.responses[dq] = numberedDesktops(4, step.current)
+			w.RuleBrightness = step.rule
+			delete(fr.errs, xi)
+			if step.idleErr != nil {
+				fr.errs[xi] = step.idleErr
+			}
+
+			err := dm.poll(context.Background())
+			if diff := cmp.Diff(step.wantErr, err, cmpTypedErr); diff != "" {
+				t.Errorf("poll() returned unexpected error (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(step.wantRun, fr.got); diff != "" {
+				t.Errorf("poll() ran unexpected commands (-want, +got):\n%s", diff)
+			}
+			if dm.dimmed != step.wantDimmed {
+				t.Errorf("poll() left dimmed as %v; want %v", dm.dimmed, step.wantDimmed)
+			}
+		})
+	}
+}
+
+func TestIdleDimmerRestoresOnExit(t *testing.T) {
+	xi := query(xprintidle)
+
+	fr := &fakeRunner{responses: map[string][]string{
+		xi: {"600000"},
+		dq: numberedDesktops(4, 0),
+		lm: {"DP-1"},
+	}}
+	dm := &idleDimmer{
+		client:  &Client{Workspace: &Workspace{}, Runner: fr},
+		after:   time.Minute,
+		percent: 50,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := dm.run(ctx); err != nil {
+		t.Fatalf("run() returned error: %v", err)
+	}
+	want := []string{
+		xi,
+		dq,
+		lm,
+		"xrandr --output DP-1 --brightness 0.50",
+		dq,
+		lm,
+		"xrandr --output DP-1 --brightness 1.00",
+	}
+	if diff := cmp.Diff(want, fr.got); diff != "" {
+		t.Errorf("run() ran unexpected commands (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,167 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	// journalLimit is the number of changes that can be undone.
+	journalLimit = 50
+)
+
+// Settings are the persisted fields of a Workspace that can be undone.
+// Navigation state (e.g. Prev) isn't included since undo only reverts
+// configuration changes.
+type Settings struct {
+	Profiles              map[int]*Profile   `json:",omitempty"`
+	SkipEmpty             bool               `json:",omitempty"`
+	Scratch               *int               `json:",omitempty"`
+	PerMonitor            bool               `json:",omitempty"`
+	LockedMonitors        []string           `json:",omitempty"`
+	Presets               map[string]*Preset `json:",omitempty"`
+	Rules                 []*BrightnessRule  `json:",omitempty"`
+	PresentationWorkspace *int               `json:",omitempty"`
+}
+
+// Journal is the history of settings changes.
+type Journal struct {
+	// Undo is the settings from before each change, oldest first.
+	Undo []*Settings `json:",omitempty"`
+	// Redo is the settings from before each undo, oldest first.
+	Redo []*Settings `json:",omitempty"`
+}
+
+// settings returns a deep copy of the workspace's settings along with its
+// JSON encoding.
+func (w *Workspace) settings() (*Settings, []byte, error) {
+	b, err := json.Marshal(&Settings{
+		Profiles:              w.Profiles,
+		SkipEmpty:             w.SkipEmpty,
+		Scratch:               w.Scratch,
+		PerMonitor:            w.PerMonitor,
+		LockedMonitors:        w.LockedMonitors,
+		Presets:               w.Presets,
+		Rules:                 w.Rules,
+		PresentationWorkspace: w.PresentationWorkspace,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy settings: %v", err)
+	}
+	s := &Settings{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, nil, fmt.Errorf("failed to copy settings: %v", err)
+	}
+	return s, b, nil
+}
+
+func (w *Workspace) restoreSettings(s *Settings) {
+	w.Profiles = s.Profiles
+	w.SkipEmpty = s.SkipEmpty
+	w.Scratch = s.Scratch
+	w.PerMonitor = s.PerMonitor
+	w.LockedMonitors = s.LockedMonitors
+	w.Presets = s.Presets
+	w.Rules = s.Rules
+	w.PresentationWorkspace = s.PresentationWorkspace
+	w.changed = true
+}
+
+// journal runs f and, if f changes any settings, records the settings from
+// before f in the undo journal.
+func (w *Workspace) journal(f func() error) error {
+	before, bb, err := w.settings()
+	if err != nil {
+		return err
+	}
+	if err := f(); err != nil {
+		return err
+	}
+	_, ab, err := w.settings()
+	if err != nil {
+		return err
+	}
+	if string(bb) == string(ab) {
+		return nil
+	}
+	if w.Journal == nil {
+		w.Journal = &Journal{}
+	}
+	w.Journal.Undo = append(w.Journal.Undo, before)
+	if len(w.Journal.Undo) > journalLimit {
+		w.Journal.Undo = w.Journal.Undo[len(w.Journal.Undo)-journalLimit:]
+	}
+	w.Journal.Redo = nil
+	w.changed = true
+	return nil
+}
+
+// journaled returns a workspaceFunc that records the settings changes made
+// by f so they can be undone.
+func journaled(f workspaceFunc) workspaceFunc {
+	return func(w *Workspace, o command.Output, d *command.Data) ([]string, error) {
+		var r []string
+		err := w.journal(func() error {
+			var err error
+			r, err = f(w, o, d)
+			return err
+		})
+		return r, err
+	}
+}
+
+// revert pops the latest settings from one side of the journal, pushes the
+// current settings onto the other side, and restores the popped settings.
+// The returned executables update the monitors if the current workspace's
+// brightness changed.
+func (w *Workspace) revert(redo bool, e env) ([]string, error) {
+	op := "undo"
+	if redo {
+		op = "redo"
+	}
+	if w.Presentation != nil {
+		return nil, fmt.Errorf("can't %s during a presentation (run `ws present stop` first)", op)
+	}
+	j := w.Journal
+	if j == nil || (!redo && len(j.Undo) == 0) || (redo && len(j.Redo) == 0) {
+		return nil, fmt.Errorf("nothing to %s", op)
+	}
+	from, to := &j.Undo, &j.Redo
+	if redo {
+		from, to = &j.Redo, &j.Undo
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	cur, _, err := w.settings()
+	if err != nil {
+		return nil, err
+	}
+
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, cur)
+	before := w.brightness(c)
+	w.restoreSettings(s)
+
+	if b := w.brightness(c); b != before {
+		mcs, err := e.monitors()
+		if err != nil {
+			e.warn(err, "Failed to get monitor codes")
+			return nil, nil
+		}
+		return w.setBrightness(mcs, b), nil
+	}
+	return nil, nil
+}
+
+func (w *Workspace) undo(o command.Output, d *command.Data) ([]string, error) {
+	return w.revert(false, newCLIEnv(o, d))
+}
+
+func (w *Workspace) redo(o command.Output, d *command.Data) ([]string, error) {
+	return w.revert(true, newCLIEnv(o, d))
+}
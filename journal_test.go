@@ -0,0 +1,196 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestJournal(t *testing.T) {
+	w := &Workspace{}
+	fr := &fakeRunner{responses: map[string][]string{
+		dq: numberedDesktops(4, 1),
+		lm: {"DP-1"},
+	}}
+	c := &Client{Workspace: w, Runner: fr}
+
+	for _, step := range []struct {
+		name    string
+		f       func() ([]string, error)
+		want    *Workspace
+		wantRun []string
+		wantErr error
+	}{
+		{
+			name:    "fails to undo without changes",
+			f:       func() ([]string, error) { return w.revert(false, c.env(context.Background())) },
+			want:    &Workspace{},
+			wantErr: fmt.Errorf("nothing to undo"),
+		},
+		{
+			name: "journals a change",
+			f: func() ([]string, error) {
+				return nil, w.journal(func() error { return w.setBrightnessFor(1, 40) })
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{1: {Brightness: 40}},
+				Journal:  &Journal{Undo: []*Settings{{}}},
+			},
+		},
+		{
+			name: "doesn't journal a failed change",
+			f: func() ([]string, error) {
+				return nil, w.journal(func() error { return w.setBrightnessFor(1, 400) })
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{1: {Brightness: 40}},
+				Journal:  &Journal{Undo: []*Settings{{}}},
+			},
+			wantErr: &BrightnessRangeError{400},
+		},
+		{
+			name: "doesn't journal navigation",
+			f: func() ([]string, error) {
+				return nil, w.journal(func() error {
+					w.Prev = intPtr(3)
+					return nil
+				})
+			},
+			want: &Workspace{
+				Prev:     intPtr(3),
+				Profiles: map[int]*Profile{1: {Brightness: 40}},
+				Journal:  &Journal{Undo: []*Settings{{}}},
+			},
+		},
+		{
+			name: "journals another change",
+			f: func() ([]string, error) {
+				return nil, w.journal(func() error {
+					w.SkipEmpty = true
+					return w.setBrightnessFor(2, 70)
+				})
+			},
+			want: &Workspace{
+				Prev:      intPtr(3),
+				SkipEmpty: true,
+				Profiles:  map[int]*Profile{1: {Brightness: 40}, 2: {Brightness: 70}},
+				Journal: &Journal{Undo: []*Settings{
+					{},
+					{Profiles: map[int]*Profile{1: {Brightness: 40}}},
+				}},
+			},
+		},
+		{
+			name: "undoes change that doesn't affect the current workspace",
+			f:    func() ([]string, error) { return w.revert(false, c.env(context.Background())) },
+			want: &Workspace{
+				Prev:     intPtr(3),
+				Profiles: map[int]*Profile{1: {Brightness: 40}},
+				Journal: &Journal{
+					Undo: []*Settings{{}},
+					Redo: []*Settings{{
+						SkipEmpty: true,
+						Profiles:  map[int]*Profile{1: {Brightness: 40}, 2: {Brightness: 70}},
+					}},
+				},
+			},
+			wantRun: []string{dq},
+		},
+		{
+			name: "undoes change to the current workspace",
+			f:    func() ([]string, error) { return w.revert(false, c.env(context.Background())) },
+			want: &Workspace{
+				Prev: intPtr(3),
+				Journal: &Journal{
+					Redo: []*Settings{
+						{
+							SkipEmpty: true,
+							Profiles:  map[int]*Profile{1: {Brightness: 40}, 2: {Brightness: 70}},
+						},
+						{Profiles: map[int]*Profile{1: {Brightness: 40}}},
+					},
+				},
+			},
+			wantRun: []string{dq, lm, "xrandr --output DP-1 --brightness 1.00"},
+		},
+		{
+			name: "redoes change",
+			f:    func() ([]string, error) { return w.revert(true, c.env(context.Background())) },
+			want: &Workspace{
+				Prev:     intPtr(3),
+				Profiles: map[int]*Profile{1: {Brightness: 40}},
+				Journal: &Journal{
+					Undo: []*Settings{{}},
+					Redo: []*Settings{{
+						SkipEmpty: true,
+						Profiles:  map[int]*Profile{1: {Brightness: 40}, 2: {Brightness: 70}},
+					}},
+				},
+			},
+			wantRun: []string{dq, lm, "xrandr --output DP-1 --brightness 0.40"},
+		},
+		{
+			name: "new change clears redo",
+			f: func() ([]string, error) {
+				return nil, w.journal(func() error { return w.setBrightnessFor(0, 90) })
+			},
+			want: &Workspace{
+				Prev:     intPtr(3),
+				Profiles: map[int]*Profile{0: {Brightness: 90}, 1: {Brightness: 40}},
+				Journal: &Journal{Undo: []*Settings{
+					{},
+					{Profiles: map[int]*Profile{1: {Brightness: 40}}},
+				}},
+			},
+		},
+		{
+			name:    "fails to redo without undoing",
+			f:       func() ([]string, error) { return w.revert(true, c.env(context.Background())) },
+			wantErr: fmt.Errorf("nothing to redo"),
+			want: &Workspace{
+				Prev:     intPtr(3),
+				Profiles: map[int]*Profile{0: {Brightness: 90}, 1: {Brightness: 40}},
+				Journal: &Journal{Undo: []*Settings{
+					{},
+					{Profiles: map[int]*Profile{1: {Brightness: 40}}},
+				}},
+			},
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			fr.got = nil
+			r, err := step.f()
+			if diff := cmp.Diff(step.wantErr, err, cmpTypedErr); diff != "" {
+				t.Errorf("returned unexpected error (-want, +got):\n%s", diff)
+			}
+			if err := c.env(context.Background()).runAll(r); err != nil {
+				t.Fatalf("failed to run executables: %v", err)
+			}
+			if diff := cmp.Diff(step.wantRun, fr.got); diff != "" {
+				t.Errorf("ran unexpected commands (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(step.want, w, cmpopts.IgnoreUnexported(Workspace{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("produced unexpected workspace (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJournalLimit(t *testing.T) {
+	w := &Workspace{}
+	for i := 0; i < journalLimit+5; i++ {
+		if err := w.journal(func() error { return w.setBrightnessFor(0, minBrightness+i) }); err != nil {
+			t.Fatalf("journal() returned error: %v", err)
+		}
+	}
+	if got := len(w.Journal.Undo); got != journalLimit {
+		t.Fatalf("journal has %d entries; want %d", got, journalLimit)
+	}
+	// The oldest entries are dropped.
+	if got, want := w.Journal.Undo[0].Profiles[0].Brightness, minBrightness+4; got != want {
+		t.Errorf("oldest journal entry has brightness %d; want %d", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	tableFormat = "table"
+)
+
+var (
+	listFormats = []string{tableFormat, jsonFormat}
+
+	listFormatFlag = command.Flag[string]("format", 'f', "Output format", command.Default(tableFormat), command.SimpleCompleter[string](listFormats...), command.InList(listFormats...))
+)
+
+type listEntry struct {
+	Index             int    `json:"index"`
+	Name              string `json:"name"`
+	Current           bool   `json:"current"`
+	Previous          bool   `json:"previous"`
+	Windows           int    `json:"windows"`
+	Brightness        int    `json:"brightness"`
+	DefaultBrightness bool   `json:"defaultBrightness"`
+}
+
+func (w *Workspace) listEntries(desktops []*desktop, windows map[int]int) []*listEntry {
+	var r []*listEntry
+	for _, d := range desktops {
+		p := w.Profiles[d.index]
+		configured := p != nil && p.Brightness != 0
+		r = append(r, &listEntry{
+			Index:             d.index,
+			Name:              d.name,
+			Current:           d.current,
+			Previous:          w.Prev != nil && d.index == *w.Prev,
+			Windows:           windows[d.index],
+			Brightness:        w.brightness(d.index),
+			DefaultBrightness: !configured,
+		})
+	}
+	return r
+}
+
+func formatList(format string, entries []*listEntry) (string, error) {
+	switch format {
+	case jsonFormat:
+		return marshalJSON(entries)
+	case tableFormat:
+		sb := &strings.Builder{}
+		tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tINDEX\tNAME\tWINDOWS\tBRIGHTNESS")
+		for _, e := range entries {
+			var marker string
+			if e.Current {
+				marker = "*"
+			} else if e.Previous {
+				marker = "-"
+			}
+			b := fmt.Sprintf("%d", e.Brightness)
+			if e.DefaultBrightness {
+				b += " (default)"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\n", marker, e.Index, e.Name, e.Windows, b)
+		}
+		if err := tw.Flush(); err != nil {
+			return "", fmt.Errorf("failed to format table: %v", err)
+		}
+		return strings.TrimSuffix(sb.String(), "\n"), nil
+	}
+	return "", fmt.Errorf("unknown list format: %q", format)
+}
+
+func (w *Workspace) list(o command.Output, d *command.Data) error {
+	e := newCLIEnv(o, d)
+	desktops, err := e.desktops()
+	if err != nil {
+		return o.Err(err)
+	}
+	windows, err := windowCounts(e)
+	if err != nil {
+		return o.Err(err)
+	}
+	s, err := formatList(listFormatFlag.Get(d), w.forDisplay(displayFlag.Get(d)).listEntries(desktops, windows))
+	if err != nil {
+		return o.Err(err)
+	}
+	o.Stdoutln(s)
+	return nil
+}
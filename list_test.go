@@ -0,0 +1,46 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListEntriesPrevious(t *testing.T) {
+	desktops := []*desktop{
+		{index: 0, name: "main"},
+		{index: 1, name: "web", current: true},
+		{index: 2, name: "code"},
+	}
+	for _, test := range []struct {
+		name string
+		w    *Workspace
+		want []bool
+	}{
+		{
+			name: "doesn't mark a previous workspace before any move",
+			w:    &Workspace{},
+			want: []bool{false, false, false},
+		},
+		{
+			name: "doesn't mark a previous workspace in per-monitor mode",
+			w:    &Workspace{PerMonitor: true},
+			want: []bool{false, false, false},
+		},
+		{
+			name: "marks the previous workspace",
+			w:    &Workspace{Prev: intPtr(0)},
+			want: []bool{true, false, false},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []bool
+			for _, e := range test.w.listEntries(desktops, nil) {
+				got = append(got, e.Previous)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("listEntries() marked unexpected previous workspaces (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	outputArg   = "OUTPUT"
+	sourceArg   = "SOURCE"
+	relativeArg = "RELATIVE_TO"
+	rotationArg = "ROTATION"
+	modeArg     = "MODE"
+)
+
+var (
+	// xrandrQuery lists every output along with its supported modes.
+	xrandrQuery = &command.BashCommand[[]string]{
+		ArgName:  "xrandr",
+		Contents: []string{"xrandr --query"},
+	}
+
+	// e.g. "eDP-1 connected primary 1920x1080+0+0 (normal left ...) 344mm x 193mm"
+	xrandrOutputRegex = regexp.MustCompile(`^(\S+) (connected|disconnected)( primary)?(?: \d+x\d+\+(\d+)\+(\d+))?(?: (left|right|inverted))?`)
+	// e.g. "   1920x1080     60.01*+  59.97    59.96"
+	xrandrModeRegex = regexp.MustCompile(`^\s+(\d+x\d+\S*)\s*(.*)$`)
+)
+
+func MonCLI() *Mon {
+	return &Mon{}
+}
+
+// Mon is a CLI for turning on, arranging and configuring xrandr outputs. It
+// doesn't store any state.
+type Mon struct{}
+
+func (*Mon) Name() string {
+	return "mon"
+}
+
+func (*Mon) Changed() bool {
+	return false
+}
+
+func (*Mon) Setup() []string {
+	return nil
+}
+
+// xrandrOutput is an output from `xrandr --query`.
+type xrandrOutput struct {
+	name      string
+	connected bool
+	primary   bool
+	// modes are the supported modes, in the order that xrandr lists them.
+	modes []string
+	// current is the active mode, or empty if the output is off.
+	current string
+	// pos is the position of the output, or nil if the output is off.
+	pos *point
+	// rotation is the output's rotation, or empty if it isn't rotated.
+	rotation string
+}
+
+func (o *xrandrOutput) String() string {
+	parts := []string{o.name}
+	if o.current != "" {
+		parts = append(parts, o.current)
+	} else if o.connected {
+		parts = append(parts, "off")
+	}
+	if !o.connected {
+		parts = append(parts, "(disconnected)")
+	}
+	if o.primary {
+		parts = append(parts, "(primary)")
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseXrandr(lines []string) []*xrandrOutput {
+	var r []*xrandrOutput
+	for _, line := range lines {
+		if m := xrandrOutputRegex.FindStringSubmatch(line); m != nil {
+			o := &xrandrOutput{
+				name:      m[1],
+				connected: m[2] == "connected",
+				primary:   m[3] != "",
+				rotation:  m[6],
+			}
+			if m[4] != "" {
+				// The regex guarantees that these are integers.
+				x, _ := strconv.Atoi(m[4])
+				y, _ := strconv.Atoi(m[5])
+				o.pos = &point{x, y}
+			}
+			r = append(r, o)
+			continue
+		}
+		// Mode lines are indented under the output that they belong to.
+		m := xrandrModeRegex.FindStringSubmatch(line)
+		if m == nil || len(r) == 0 {
+			continue
+		}
+		o := r[len(r)-1]
+		o.modes = append(o.modes, m[1])
+		if strings.Contains(m[2], "*") {
+			o.current = m[1]
+		}
+	}
+	return r
+}
+
+func findOutput(outputs []*xrandrOutput, name string) *xrandrOutput {
+	for _, o := range outputs {
+		if o.name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// outputCompleter suggests the connected outputs, or every output xrandr
+// knows about if all is true (disconnected outputs may still be on after
+// undocking).
+func outputCompleter(all bool) command.Completer[string] {
+	return command.CompleterFromFunc(func(s string, d *command.Data) (*command.Completion, error) {
+		if !all {
+			mcs, err := completionRunner.Run(context.Background(), listMcs.Contents[0])
+			if err != nil {
+				return nil, nil
+			}
+			return &command.Completion{Suggestions: mcs}, nil
+		}
+		lines, err := completionRunner.Run(context.Background(), xrandrQuery.Contents[0])
+		if err != nil {
+			return nil, nil
+		}
+		var r []string
+		for _, o := range parseXrandr(lines) {
+			r = append(r, o.name)
+		}
+		return &command.Completion{Suggestions: r}, nil
+	})
+}
+
+// modeCompleter suggests the modes supported by the output in the OUTPUT
+// argument.
+func modeCompleter() command.Completer[string] {
+	return command.CompleterFromFunc(func(s string, d *command.Data) (*command.Completion, error) {
+		lines, err := completionRunner.Run(context.Background(), xrandrQuery.Contents[0])
+		if err != nil {
+			return nil, nil
+		}
+		o := findOutput(parseXrandr(lines), d.String(outputArg))
+		if o == nil {
+			return nil, nil
+		}
+		return &command.Completion{Suggestions: o.modes}, nil
+	})
+}
+
+// checkConnected fails if any of the provided arguments isn't a connected
+// output. It must come after listMcs.
+func checkConnected(args ...string) command.Processor {
+	return command.SuperSimpleProcessor(func(i *command.Input, d *command.Data) error {
+		mcs := d.StringList(listMcs.ArgName)
+		for _, a := range args {
+			name := d.String(a)
+			found := false
+			for _, mc := range mcs {
+				if strings.TrimSpace(mc) == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("output %q is not connected", name)
+			}
+		}
+		return nil
+	})
+}
+
+// xrandr returns a processor that runs the xrandr command produced by f.
+func xrandr(f func(d *command.Data) (string, error)) command.Processor {
+	return command.ExecutableProcessor(func(o command.Output, d *command.Data) ([]string, error) {
+		c, err := f(d)
+		if err != nil {
+			return nil, o.Err(err)
+		}
+		return onDisplay(displayFlag.Get(d), []string{c}), nil
+	})
+}
+
+// outputCommand returns the xrandr command that applies opts to OUTPUT.
+func outputCommand(opts string) func(*command.Data) (string, error) {
+	return func(d *command.Data) (string, error) {
+		return fmt.Sprintf("xrandr --output %s %s", d.String(outputArg), opts), nil
+	}
+}
+
+func turnOff(d *command.Data) (string, error) {
+	name := d.String(outputArg)
+	if findOutput(parseXrandr(d.StringList(xrandrQuery.ArgName)), name) == nil {
+		return "", fmt.Errorf("output %q does not exist", name)
+	}
+	return fmt.Sprintf("xrandr --output %s --off", name), nil
+}
+
+func rotate(d *command.Data) (string, error) {
+	return fmt.Sprintf("xrandr --output %s --rotate %s", d.String(outputArg), d.String(rotationArg)), nil
+}
+
+func mirror(d *command.Data) (string, error) {
+	return fmt.Sprintf("xrandr --output %s --auto --same-as %s", d.String(outputArg), d.String(sourceArg)), nil
+}
+
+func extend(position string) func(*command.Data) (string, error) {
+	return func(d *command.Data) (string, error) {
+		return fmt.Sprintf("xrandr --output %s --auto --%s %s", d.String(outputArg), position, d.String(relativeArg)), nil
+	}
+}
+
+func setMode(d *command.Data) (string, error) {
+	name, mode := d.String(outputArg), d.String(modeArg)
+	o := findOutput(parseXrandr(d.StringList(xrandrQuery.ArgName)), name)
+	if o == nil {
+		return "", fmt.Errorf("output %q does not exist", name)
+	}
+	for _, m := range o.modes {
+		if m == mode {
+			return fmt.Sprintf("xrandr --output %s --mode %s", name, mode), nil
+		}
+	}
+	return "", fmt.Errorf("output %q does not support mode %q (supported modes: %s)", name, mode, strings.Join(o.modes, ", "))
+}
+
+func listOutputs(o command.Output, d *command.Data) error {
+	for _, x := range parseXrandr(d.StringList(xrandrQuery.ArgName)) {
+		o.Stdoutln(x)
+	}
+	return nil
+}
+
+func (m *Mon) Node() command.Node {
+	output := command.Arg[string](outputArg, "Output name (from `ws monitors list`)", outputCompleter(false))
+	extendNode := func(position string) command.Node {
+		return command.SerialNodes(
+			command.Description(fmt.Sprintf("Turn on an output and place it %s another output", strings.ReplaceAll(position, "-", " "))),
+			command.FlagNode(displayFlag), useDisplay,
+			output,
+			command.Arg[string](relativeArg, "Output to place OUTPUT next to", outputCompleter(false)),
+			listMcs, checkConnected(outputArg, relativeArg),
+			xrandr(extend(position)),
+		)
+	}
+	return &command.BranchNode{
+		Branches: map[string]command.Node{
+			"list": command.SerialNodes(
+				command.Description("List outputs along with their current modes"),
+				command.FlagNode(displayFlag), useDisplay,
+				xrandrQuery,
+				&command.ExecutorProcessor{F: listOutputs},
+			),
+			"on": command.SerialNodes(
+				command.Description("Turn on an output at its preferred mode"),
+				command.FlagNode(displayFlag), useDisplay,
+				output,
+				listMcs, checkConnected(outputArg),
+				xrandr(outputCommand("--auto")),
+			),
+			"off": command.SerialNodes(
+				command.Description("Turn off an output"),
+				command.FlagNode(displayFlag), useDisplay,
+				command.Arg[string](outputArg, "Output name (from `xrandr --query`)", outputCompleter(true)),
+				xrandrQuery,
+				xrandr(turnOff),
+			),
+			"primary": command.SerialNodes(
+				command.Description("Make an output the primary output"),
+				command.FlagNode(displayFlag), useDisplay,
+				output,
+				listMcs, checkConnected(outputArg),
+				xrandr(outputCommand("--primary")),
+			),
+			"rotate": command.SerialNodes(
+				command.Description("Rotate an output"),
+				command.FlagNode(displayFlag), useDisplay,
+				output,
+				command.Arg[string](rotationArg, "Rotation", command.InList("normal", "left", "right", "inverted"), command.SimpleCompleter[string]("normal", "left", "right", "inverted")),
+				listMcs, checkConnected(outputArg),
+				xrandr(rotate),
+			),
+			"mirror": command.SerialNodes(
+				command.Description("Show the same content on an output as on another output"),
+				command.FlagNode(displayFlag), useDisplay,
+				output,
+				command.Arg[string](sourceArg, "Output to mirror", outputCompleter(false)),
+				listMcs, checkConnected(outputArg, sourceArg),
+				xrandr(mirror),
+			),
+			"extend": &command.BranchNode{
+				Branches: map[string]command.Node{
+					"left-of":  extendNode("left-of"),
+					"right-of": extendNode("right-of"),
+					"above":    extendNode("above"),
+					"below":    extendNode("below"),
+				},
+			},
+			"mode": command.SerialNodes(
+				command.Description("Set the resolution of an output"),
+				command.FlagNode(displayFlag), useDisplay,
+				output,
+				command.Arg[string](modeArg, "Mode (from `xrandr --query`)", modeCompleter()),
+				xrandrQuery,
+				xrandr(setMode),
+			),
+		},
+	}
+}
@@ -0,0 +1,326 @@
+package workspace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leep-frog/command"
+)
+
+var xrandrLines = []string{
+	"Screen 0: minimum 8 x 8, current 3840 x 1080, maximum 32767 x 32767",
+	"eDP-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 344mm x 193mm",
+	"   1920x1080     60.01*+  59.97    59.96    59.93  ",
+	"   1680x1050     59.95    59.88  ",
+	"DP-1 disconnected (normal left inverted right x axis y axis)",
+	"HDMI-1 connected (normal left inverted right x axis y axis)",
+	"   2560x1440     59.95 +",
+	"   1920x1080i    60.00    50.00  ",
+	"DP-2 disconnected 1080x1920+1920+0 left (normal left inverted right x axis y axis) 0mm x 0mm",
+	"   1920x1080     60.00*",
+}
+
+func TestParseXrandr(t *testing.T) {
+	got := parseXrandr(xrandrLines)
+	want := []*xrandrOutput{
+		{name: "eDP-1", connected: true, primary: true, modes: []string{"1920x1080", "1680x1050"}, current: "1920x1080", pos: &point{0, 0}},
+		{name: "DP-1"},
+		{name: "HDMI-1", connected: true, modes: []string{"2560x1440", "1920x1080i"}},
+		{name: "DP-2", modes: []string{"1920x1080"}, current: "1920x1080", pos: &point{1920, 0}, rotation: "left"},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(xrandrOutput{}, point{})); diff != "" {
+		t.Errorf("parseXrandr() returned diff (-want, +got):\n%s", diff)
+	}
+
+	var strs []string
+	for _, o := range got {
+		strs = append(strs, o.String())
+	}
+	wantStrs := []string{
+		"eDP-1 1920x1080 (primary)",
+		"DP-1 (disconnected)",
+		"HDMI-1 off",
+		"DP-2 1920x1080 (disconnected)",
+	}
+	if diff := cmp.Diff(wantStrs, strs); diff != "" {
+		t.Errorf("xrandrOutput.String() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMon(t *testing.T) {
+	xqRun := &command.FakeRun{Stdout: xrandrLines}
+
+	for _, test := range []struct {
+		name string
+		etc  *command.ExecuteTestCase
+	}{
+		{
+			name: "lists outputs",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"list"},
+				RunResponses:    []*command.FakeRun{xqRun},
+				WantRunContents: [][]string{xqCmd},
+				WantStdout: "eDP-1 1920x1080 (primary)\n" +
+					"DP-1 (disconnected)\n" +
+					"HDMI-1 off\n" +
+					"DP-2 1920x1080 (disconnected)\n",
+				WantData: &command.Data{Values: map[string]interface{}{
+					"xrandr": xrandrLines,
+				}},
+			},
+		},
+		{
+			name: "turns output on",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"on", "HDMI-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --auto"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					"mcs":     []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "turns output on for display",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"on", "HDMI-1", "-d", ":1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"DISPLAY=:1 xrandr --output HDMI-1 --auto"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg:          "HDMI-1",
+					displayFlag.Name(): ":1",
+					"mcs":              []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "fails to turn on disconnected output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"on", "DP-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantErr:         fmt.Errorf(`output "DP-1" is not connected`),
+				WantStderr:      "output \"DP-1\" is not connected\n",
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "DP-1",
+					"mcs":     []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "turns off disconnected output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"off", "DP-2"},
+				RunResponses:    []*command.FakeRun{xqRun},
+				WantRunContents: [][]string{xqCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output DP-2 --off"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "DP-2",
+					"xrandr":  xrandrLines,
+				}},
+			},
+		},
+		{
+			name: "fails to turn off unknown output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"off", "VGA-1"},
+				RunResponses:    []*command.FakeRun{xqRun},
+				WantRunContents: [][]string{xqCmd},
+				WantErr:         fmt.Errorf(`output "VGA-1" does not exist`),
+				WantStderr:      "output \"VGA-1\" does not exist\n",
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "VGA-1",
+					"xrandr":  xrandrLines,
+				}},
+			},
+		},
+		{
+			name: "sets primary output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"primary", "HDMI-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --primary"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					"mcs":     []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "rotates output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"rotate", "eDP-1", "left"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output eDP-1 --rotate left"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg:   "eDP-1",
+					rotationArg: "left",
+					"mcs":       []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "mirrors output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"mirror", "HDMI-1", "eDP-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --auto --same-as eDP-1"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					sourceArg: "eDP-1",
+					"mcs":     []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "fails to mirror disconnected output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"mirror", "HDMI-1", "DP-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantErr:         fmt.Errorf(`output "DP-1" is not connected`),
+				WantStderr:      "output \"DP-1\" is not connected\n",
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					sourceArg: "DP-1",
+					"mcs":     []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "extends output to the left",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"extend", "left-of", "HDMI-1", "eDP-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --auto --left-of eDP-1"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg:   "HDMI-1",
+					relativeArg: "eDP-1",
+					"mcs":       []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "extends output above",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"extend", "above", "HDMI-1", "eDP-1"},
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --auto --above eDP-1"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg:   "HDMI-1",
+					relativeArg: "eDP-1",
+					"mcs":       []string{"eDP-1", "HDMI-1"},
+				}},
+			},
+		},
+		{
+			name: "sets mode",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"mode", "HDMI-1", "1920x1080i"},
+				RunResponses:    []*command.FakeRun{xqRun},
+				WantRunContents: [][]string{xqCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{"xrandr --output HDMI-1 --mode 1920x1080i"},
+				},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					modeArg:   "1920x1080i",
+					"xrandr":  xrandrLines,
+				}},
+			},
+		},
+		{
+			name: "fails for unsupported mode",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"mode", "HDMI-1", "1680x1050"},
+				RunResponses:    []*command.FakeRun{xqRun},
+				WantRunContents: [][]string{xqCmd},
+				WantErr:         fmt.Errorf(`output "HDMI-1" does not support mode "1680x1050" (supported modes: 2560x1440, 1920x1080i)`),
+				WantStderr:      "output \"HDMI-1\" does not support mode \"1680x1050\" (supported modes: 2560x1440, 1920x1080i)\n",
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+					modeArg:   "1680x1050",
+					"xrandr":  xrandrLines,
+				}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldSetenv := setenv
+			setenv = func(string, string) error { return nil }
+			defer func() { setenv = oldSetenv }()
+
+			test.etc.Node = MonCLI().Node()
+			command.ExecuteTest(t, test.etc)
+		})
+	}
+}
+
+func TestMonCompletion(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ctc  *command.CompleteTestCase
+	}{
+		{
+			name: "completes connected outputs",
+			ctc: &command.CompleteTestCase{
+				Args: "cmd on ",
+				Want: []string{"HDMI-1", "eDP-1"},
+			},
+		},
+		{
+			name: "completes every output for off",
+			ctc: &command.CompleteTestCase{
+				Args: "cmd off ",
+				Want: []string{"DP-1", "DP-2", "HDMI-1", "eDP-1"},
+			},
+		},
+		{
+			name: "completes modes for output",
+			ctc: &command.CompleteTestCase{
+				Args: "cmd mode HDMI-1 ",
+				Want: []string{"1920x1080i", "2560x1440"},
+				WantData: &command.Data{Values: map[string]interface{}{
+					outputArg: "HDMI-1",
+				}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldRunner := completionRunner
+			completionRunner = &fakeRunner{responses: map[string][]string{
+				lm:                      {"eDP-1", "HDMI-1"},
+				xrandrQuery.Contents[0]: xrandrLines,
+			}}
+			defer func() { completionRunner = oldRunner }()
+
+			test.ctc.Node = MonCLI().Node()
+			command.CompleteTest(t, test.ctc)
+		})
+	}
+}
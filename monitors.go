@@ -0,0 +1,57 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+// locked returns whether the brightness of monitor mc must not be changed.
+func (w *Workspace) locked(mc string) bool {
+	mc = strings.TrimSpace(mc)
+	for _, l := range w.LockedMonitors {
+		if l == mc {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Workspace) lockMonitor(o command.Output, d *command.Data) error {
+	mc := strings.TrimSpace(d.String(monitorArg))
+	if w.locked(mc) {
+		return nil
+	}
+	w.LockedMonitors = append(w.LockedMonitors, mc)
+	sort.Strings(w.LockedMonitors)
+	w.changed = true
+	return nil
+}
+
+func (w *Workspace) unlockMonitor(o command.Output, d *command.Data) error {
+	mc := strings.TrimSpace(d.String(monitorArg))
+	for i, l := range w.LockedMonitors {
+		if l == mc {
+			w.LockedMonitors = append(w.LockedMonitors[:i], w.LockedMonitors[i+1:]...)
+			w.changed = true
+			return nil
+		}
+	}
+	return fmt.Errorf("monitor %q is not locked", mc)
+}
+
+func (w *Workspace) listMonitors(o command.Output, d *command.Data) error {
+	t := w.forDisplay(displayFlag.Get(d))
+	codes := d.StringList(listMcs.ArgName)
+	sort.Strings(codes)
+	for _, c := range codes {
+		if t.locked(c) {
+			o.Stdoutf("%s (locked)\n", c)
+		} else {
+			o.Stdoutln(c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	skipEmptyArg = "SKIP_EMPTY"
+)
+
+// windowCounts returns the number of windows on each workspace.
+func windowCounts(e env) (map[int]int, error) {
+	lines, err := e.windows()
+	if err != nil {
+		return nil, err
+	}
+	return parseWindowCounts(lines)
+}
+
+// nextOccupied returns the first workspace, starting from c and moving in
+// the direction of dir, that has at least one window. The second return
+// value is false if no other workspace has any windows.
+func nextOccupied(c, n, dir int, windows map[int]int) (int, bool) {
+	for i := 1; i < n; i++ {
+		ws := wrapWorkspace(c+dir*i, n)
+		if windows[ws] > 0 {
+			return ws, true
+		}
+	}
+	return c, false
+}
+
+func (w *Workspace) moveOccupied(dir int, e env) ([]string, error) {
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowCounts(e)
+	if err != nil {
+		return nil, err
+	}
+	ws, ok := nextOccupied(c, n, dir, windows)
+	if !ok {
+		return nil, fmt.Errorf("no other workspaces have any windows")
+	}
+	return w.moveTo(ws, e)
+}
+
+func (w *Workspace) moveNextOccupied(o command.Output, d *command.Data) ([]string, error) {
+	return w.moveOccupied(1, newCLIEnv(o, d))
+}
+
+func (w *Workspace) movePrevOccupied(o command.Output, d *command.Data) ([]string, error) {
+	return w.moveOccupied(-1, newCLIEnv(o, d))
+}
+
+func (w *Workspace) moveEmpty(o command.Output, d *command.Data) ([]string, error) {
+	e := newCLIEnv(o, d)
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowCounts(e)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		if windows[i] == 0 {
+			return w.moveTo(i, e)
+		}
+	}
+	return nil, fmt.Errorf("all %d workspaces have windows", n)
+}
+
+func (w *Workspace) setSkipEmpty(o command.Output, d *command.Data) error {
+	w.SkipEmpty = d.Bool(skipEmptyArg)
+	w.changed = true
+	return nil
+}
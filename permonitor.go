@@ -0,0 +1,206 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	perMonitorArg = "PER_MONITOR"
+	directionArg  = "DIRECTION"
+)
+
+var (
+	// listOutputWorkspaces prints the workspaces of every output as JSON.
+	// Workspace commands still list desktops with wmctrl, which doesn't work
+	// on Wayland compositors like sway, so only i3 is supported.
+	listOutputWorkspaces = &command.BashCommand[[]string]{
+		ArgName:  "outputWorkspaces",
+		Contents: []string{"i3-msg -t get_workspaces"},
+	}
+)
+
+// outputWorkspace is a single workspace as reported by
+// `i3-msg -t get_workspaces`.
+type outputWorkspace struct {
+	Num     int    `json:"num"`
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Focused bool   `json:"focused"`
+	Output  string `json:"output"`
+}
+
+// focusedOutput is the numbered workspaces on the focused output.
+type focusedOutput struct {
+	name string
+	// nums are the workspace numbers on the output, in ascending order.
+	nums []int
+	// names are the names of the workspaces on the output by number.
+	names map[int]string
+	// current is the workspace number that is visible on the output.
+	current int
+}
+
+// index returns the position of workspace number num on the output.
+func (fo *focusedOutput) index(num int) (int, bool) {
+	for i, n := range fo.nums {
+		if n == num {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseFocusedOutput returns the focused output from the output of
+// `i3-msg -t get_workspaces`. Workspaces without a number are ignored.
+func parseFocusedOutput(lines []string) (*focusedOutput, error) {
+	var ows []*outputWorkspace
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &ows); err != nil {
+		return nil, fmt.Errorf("failed to parse i3 workspaces: %v", err)
+	}
+	var fo *focusedOutput
+	for _, ow := range ows {
+		if ow.Focused {
+			fo = &focusedOutput{name: ow.Output, current: ow.Num, names: map[int]string{}}
+		}
+	}
+	if fo == nil {
+		return nil, fmt.Errorf("no workspace is focused")
+	}
+	for _, ow := range ows {
+		if ow.Output == fo.name && ow.Num >= 0 {
+			fo.nums = append(fo.nums, ow.Num)
+			fo.names[ow.Num] = ow.Name
+		}
+	}
+	sort.Ints(fo.nums)
+	return fo, nil
+}
+
+func getFocusedOutput(e env) (*focusedOutput, error) {
+	lines, err := e.outputWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	return parseFocusedOutput(lines)
+}
+
+// outputEnv is an env whose workspaces are the ones on a single output.
+// Workspaces are identified by their position on the output.
+type outputEnv struct {
+	env
+	fo *focusedOutput
+}
+
+func (e *outputEnv) numWorkspaces() (int, error) {
+	if len(e.fo.nums) == 0 {
+		return 0, ErrNoWorkspaces
+	}
+	return len(e.fo.nums), nil
+}
+
+func (e *outputEnv) currentWorkspace() (int, error) {
+	i, ok := e.fo.index(e.fo.current)
+	if !ok {
+		return 0, fmt.Errorf("the focused workspace on %s has no number", e.fo.name)
+	}
+	return i, nil
+}
+
+// moveOnOutput moves to the workspace at the position on the focused output
+// that is returned by f.
+func (w *Workspace) moveOnOutput(e env, f func(*outputEnv) (int, error)) ([]string, error) {
+	fo, err := getFocusedOutput(e)
+	if err != nil {
+		return nil, err
+	}
+	oe := &outputEnv{e, fo}
+	i, err := f(oe)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(fo.nums) {
+		return nil, &WorkspaceNotFoundError{Workspace: i, NumWorkspaces: len(fo.nums)}
+	}
+	return w.moveToNum(fo, fo.nums[i], e)
+}
+
+// moveToNum moves the focused output to workspace number num.
+func (w *Workspace) moveToNum(fo *focusedOutput, num int, e env) ([]string, error) {
+	if num == fo.current {
+		return nil, nil
+	}
+	// Profiles and the pending switch use the desktop index (like every other
+	// command), not the i3 workspace number.
+	index, err := fo.desktopIndex(num, e)
+	if err != nil {
+		return nil, err
+	}
+	if w.MonitorPrev == nil {
+		w.MonitorPrev = map[string]int{}
+	}
+	w.MonitorPrev[fo.name] = fo.current
+	w.changed = true
+	w.switched = &index
+	// Only the focused output changes workspace, so only its brightness is
+	// changed.
+	return append([]string{
+		fmt.Sprintf("i3-msg workspace number %d", num),
+	}, w.setBrightness([]string{fo.name}, w.brightness(index))...), nil
+}
+
+// desktopIndex returns the desktop index of workspace number num. i3 names
+// each desktop after its workspace.
+func (fo *focusedOutput) desktopIndex(num int, e env) (int, error) {
+	ds, err := e.desktops()
+	if err != nil {
+		return 0, err
+	}
+	name := fo.names[num]
+	for _, d := range ds {
+		if d.name == name {
+			return d.index, nil
+		}
+	}
+	return 0, fmt.Errorf("no desktop is named after workspace %q", name)
+}
+
+func (w *Workspace) moveRelativeOnOutput(offset int, e env) ([]string, error) {
+	return w.moveOnOutput(e, func(oe *outputEnv) (int, error) {
+		n, err := oe.numWorkspaces()
+		if err != nil {
+			return 0, err
+		}
+		c, err := oe.currentWorkspace()
+		if err != nil {
+			return 0, err
+		}
+		return wrapWorkspace(c+offset, n), nil
+	})
+}
+
+func (w *Workspace) moveBackOnOutput(e env) ([]string, error) {
+	fo, err := getFocusedOutput(e)
+	if err != nil {
+		return nil, err
+	}
+	prev, ok := w.MonitorPrev[fo.name]
+	if !ok {
+		return nil, fmt.Errorf("no previous workspace for output %s", fo.name)
+	}
+	return w.moveToNum(fo, prev, e)
+}
+
+func (w *Workspace) setPerMonitor(o command.Output, d *command.Data) error {
+	w.PerMonitor = d.Bool(perMonitorArg)
+	w.changed = true
+	return nil
+}
+
+func (w *Workspace) focusMonitor(o command.Output, d *command.Data) ([]string, error) {
+	return []string{fmt.Sprintf("i3-msg focus output %s", d.String(directionArg))}, nil
+}
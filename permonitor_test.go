@@ -0,0 +1,57 @@
+package workspace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFocusedOutput(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		lines   []string
+		want    *focusedOutput
+		wantErr error
+	}{
+		{
+			name: "parses focused output",
+			lines: []string{
+				`[{"num":1,"name":"1","visible":true,"focused":false,"output":"HDMI-1"},`,
+				`{"num":4,"name":"4: web","visible":false,"focused":false,"output":"eDP-1"},`,
+				`{"num":2,"name":"2","visible":true,"focused":true,"output":"eDP-1"},`,
+				`{"num":-1,"name":"notes","visible":false,"focused":false,"output":"eDP-1"},`,
+				`{"num":3,"name":"3","visible":false,"focused":false,"output":"HDMI-1"}]`,
+			},
+			want: &focusedOutput{
+				name: "eDP-1",
+				nums: []int{2, 4},
+				names: map[int]string{
+					2: "2",
+					4: "4: web",
+				},
+				current: 2,
+			},
+		},
+		{
+			name:    "fails if nothing is focused",
+			lines:   []string{`[{"num":1,"name":"1","visible":true,"focused":false,"output":"HDMI-1"}]`},
+			wantErr: fmt.Errorf("no workspace is focused"),
+		},
+		{
+			name:    "fails on invalid JSON",
+			lines:   []string{"not json"},
+			wantErr: fmt.Errorf("failed to parse i3 workspaces: invalid character 'o' in literal null (expecting 'u')"),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseFocusedOutput(test.lines)
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("parseFocusedOutput() returned unexpected error (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(focusedOutput{})); diff != "" {
+				t.Errorf("parseFocusedOutput() returned unexpected output (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	presentExtendFlag = command.BoolFlag("extend", 'e', "Extend the desktop onto the projector instead of mirroring it")
+)
+
+// Presentation is the state from before `ws present` that is restored by
+// `ws present stop`.
+type Presentation struct {
+	// Settings are the workspace settings from before the presentation.
+	Settings *Settings
+	// Outputs is the output layout from before the presentation.
+	Outputs []*OutputLayout
+	// Return is the workspace that was current before the presentation.
+	Return int
+	// Journal is the undo journal from before the presentation. Changes made
+	// during the presentation are discarded, so they can't be undone either.
+	Journal *Journal `json:",omitempty"`
+}
+
+// OutputLayout is the configuration of a single xrandr output.
+type OutputLayout struct {
+	Name string
+	// Mode is the output's mode. If empty, the output was off.
+	Mode     string `json:",omitempty"`
+	X        int    `json:",omitempty"`
+	Y        int    `json:",omitempty"`
+	Rotation string `json:",omitempty"`
+	Primary  bool   `json:",omitempty"`
+}
+
+// outputLayouts returns the layout of every output that is connected or on.
+func outputLayouts(outputs []*xrandrOutput) []*OutputLayout {
+	var r []*OutputLayout
+	for _, o := range outputs {
+		if !o.connected && o.current == "" {
+			continue
+		}
+		l := &OutputLayout{
+			Name:     o.name,
+			Mode:     o.current,
+			Rotation: o.rotation,
+			Primary:  o.primary,
+		}
+		if o.pos != nil {
+			l.X, l.Y = o.pos.x, o.pos.y
+		}
+		r = append(r, l)
+	}
+	return r
+}
+
+// restoreLayout returns the xrandr command that restores the provided
+// layouts. All outputs are set at once so xrandr never has to fit an
+// intermediate layout into the screen.
+func restoreLayout(layouts []*OutputLayout) string {
+	r := []string{"xrandr"}
+	for _, l := range layouts {
+		r = append(r, "--output", l.Name)
+		if l.Mode == "" {
+			r = append(r, "--off")
+			continue
+		}
+		rotation := l.Rotation
+		if rotation == "" {
+			rotation = "normal"
+		}
+		r = append(r, "--mode", l.Mode, "--pos", fmt.Sprintf("%dx%d", l.X, l.Y), "--rotate", rotation)
+		if l.Primary {
+			r = append(r, "--primary")
+		}
+	}
+	return strings.Join(r, " ")
+}
+
+// projectorOutputs returns the output to present on and the output whose
+// content it should show (or be placed next to).
+func projectorOutputs(outputs []*xrandrOutput, d *command.Data) (*xrandrOutput, *xrandrOutput, error) {
+	var projector *xrandrOutput
+	if d.Has(outputArg) {
+		name := d.String(outputArg)
+		if projector = findOutput(outputs, name); projector == nil || !projector.connected {
+			return nil, nil, fmt.Errorf("output %q is not connected", name)
+		}
+	} else {
+		var candidates []*xrandrOutput
+		var names []string
+		for _, o := range outputs {
+			if o.connected && !o.primary {
+				candidates = append(candidates, o)
+				names = append(names, o.name)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, nil, fmt.Errorf("no projector is connected")
+		}
+		if len(candidates) > 1 {
+			return nil, nil, fmt.Errorf("multiple outputs could be the projector (%s); provide OUTPUT", strings.Join(names, ", "))
+		}
+		projector = candidates[0]
+	}
+
+	// Prefer the primary output, and then any other output that is on.
+	var source *xrandrOutput
+	for _, o := range outputs {
+		if o == projector || o.current == "" {
+			continue
+		}
+		if o.primary {
+			return projector, o, nil
+		}
+		if source == nil {
+			source = o
+		}
+	}
+	if source == nil {
+		return nil, nil, fmt.Errorf("no output is on to present from")
+	}
+	return projector, source, nil
+}
+
+// present saves the current settings and output layout, turns on the
+// projector, and switches to the presentation workspace at full
+// brightness. Brightness rules are paused until the presentation stops
+// since they would dim the screen mid-presentation.
+func (w *Workspace) present(o command.Output, d *command.Data) ([]string, error) {
+	if w.Presentation != nil {
+		return nil, fmt.Errorf("a presentation is already in progress (run `ws present stop` first)")
+	}
+	outputs := parseXrandr(d.StringList(xrandrQuery.ArgName))
+	projector, source, err := projectorOutputs(outputs, d)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newCLIEnv(o, d)
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := w.settings()
+	if err != nil {
+		return nil, err
+	}
+	w.Presentation = &Presentation{
+		Settings: s,
+		Outputs:  outputLayouts(outputs),
+		Return:   c,
+		Journal:  w.Journal,
+	}
+
+	var all []int
+	for i := 0; i < n; i++ {
+		all = append(all, i)
+	}
+	w.applyPreset(&Preset{Brightness: defaultBrightness}, all)
+	w.Rules = nil
+
+	placement := "--same-as"
+	if presentExtendFlag.Get(d) {
+		placement = "--right-of"
+	}
+	r := []string{fmt.Sprintf("xrandr --output %s --auto %s %s", projector.name, placement, source.name)}
+	if w.PresentationWorkspace != nil && *w.PresentationWorkspace != c {
+		return append(r, w.switchTo(*w.PresentationWorkspace, e)...), nil
+	}
+	mcs, err := e.monitors()
+	if err != nil {
+		e.warn(err, "Failed to get monitor codes")
+		return r, nil
+	}
+	return append(r, w.setBrightness(mcs, defaultBrightness)...), nil
+}
+
+// stopPresenting restores the settings, output layout and workspace from
+// before the presentation. Settings changes made during the presentation
+// are discarded.
+func (w *Workspace) stopPresenting(o command.Output, d *command.Data) ([]string, error) {
+	p := w.Presentation
+	if p == nil {
+		return nil, fmt.Errorf("no presentation is in progress")
+	}
+	e := newCLIEnv(o, d)
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	w.restoreSettings(p.Settings)
+	w.Journal = p.Journal
+	w.Presentation = nil
+
+	var r []string
+	if len(p.Outputs) > 0 {
+		r = append(r, restoreLayout(p.Outputs))
+	}
+	if p.Return != c {
+		return append(r, w.switchTo(p.Return, e)...), nil
+	}
+	mcs, err := e.monitors()
+	if err != nil {
+		e.warn(err, "Failed to get monitor codes")
+		return r, nil
+	}
+	return append(r, w.setBrightness(mcs, w.brightness(c))...), nil
+}
+
+func (w *Workspace) setPresentationWorkspace(o command.Output, d *command.Data) error {
+	n, err := resolveWorkspace(d, newCLIEnv(o, d))
+	if err != nil {
+		return err
+	}
+	w.PresentationWorkspace = &n
+	w.changed = true
+	return nil
+}
@@ -0,0 +1,95 @@
+package workspace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leep-frog/command"
+)
+
+func TestRestoreLayout(t *testing.T) {
+	got := restoreLayout(outputLayouts(parseXrandr(xrandrLines)))
+	want := "xrandr --output eDP-1 --mode 1920x1080 --pos 0x0 --rotate normal --primary --output HDMI-1 --off --output DP-2 --mode 1920x1080 --pos 1920x0 --rotate left"
+	if got != want {
+		t.Errorf("restoreLayout() returned %q; want %q", got, want)
+	}
+}
+
+func TestProjectorOutputs(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		lines         []string
+		output        string
+		wantProjector string
+		wantSource    string
+		wantErr       error
+	}{
+		{
+			name:          "uses only connected non-primary output",
+			lines:         xrandrLines,
+			wantProjector: "HDMI-1",
+			wantSource:    "eDP-1",
+		},
+		{
+			name:          "uses provided output",
+			lines:         xrandrLines,
+			output:        "eDP-1",
+			wantProjector: "eDP-1",
+			wantSource:    "DP-2",
+		},
+		{
+			name:    "fails for disconnected output",
+			lines:   xrandrLines,
+			output:  "DP-1",
+			wantErr: fmt.Errorf(`output "DP-1" is not connected`),
+		},
+		{
+			name: "fails if no projector is connected",
+			lines: []string{
+				"eDP-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 344mm x 193mm",
+				"   1920x1080     60.01*+",
+			},
+			wantErr: fmt.Errorf("no projector is connected"),
+		},
+		{
+			name: "fails if projector is ambiguous",
+			lines: []string{
+				"eDP-1 connected primary 1920x1080+0+0 (normal left inverted right x axis y axis) 344mm x 193mm",
+				"   1920x1080     60.01*+",
+				"DP-1 connected (normal left inverted right x axis y axis)",
+				"HDMI-1 connected (normal left inverted right x axis y axis)",
+			},
+			wantErr: fmt.Errorf("multiple outputs could be the projector (DP-1, HDMI-1); provide OUTPUT"),
+		},
+		{
+			name: "fails if no output is on",
+			lines: []string{
+				"eDP-1 connected primary (normal left inverted right x axis y axis)",
+				"HDMI-1 connected (normal left inverted right x axis y axis)",
+			},
+			wantErr: fmt.Errorf("no output is on to present from"),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := &command.Data{Values: map[string]interface{}{}}
+			if test.output != "" {
+				d.Values[outputArg] = test.output
+			}
+			projector, source, err := projectorOutputs(parseXrandr(test.lines), d)
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("projectorOutputs() returned unexpected error (-want, +got):\n%s", diff)
+			}
+			var gotProjector, gotSource string
+			if projector != nil {
+				gotProjector = projector.name
+			}
+			if source != nil {
+				gotSource = source.name
+			}
+			if gotProjector != test.wantProjector || gotSource != test.wantSource {
+				t.Errorf("projectorOutputs() returned (%q, %q); want (%q, %q)", gotProjector, gotSource, test.wantProjector, test.wantSource)
+			}
+		})
+	}
+}
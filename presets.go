@@ -0,0 +1,162 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	presetArg       = "PRESET"
+	presetTargetArg = "TARGET"
+)
+
+// Preset is a named brightness setting. It is either a single Brightness
+// that can be applied to any workspace, or a mapping from workspace to
+// brightness that replaces every workspace's brightness at once.
+type Preset struct {
+	// Brightness is the brightness percentage. If zero, Workspaces is used.
+	Brightness int `json:",omitempty"`
+	// Workspaces is the brightness for each workspace. Workspaces that
+	// aren't included use the default brightness.
+	Workspaces map[int]int `json:",omitempty"`
+}
+
+func (p *Preset) String() string {
+	if p.Brightness != 0 {
+		return fmt.Sprintf("%d", p.Brightness)
+	}
+	var ks []int
+	for k := range p.Workspaces {
+		ks = append(ks, k)
+	}
+	sort.Ints(ks)
+	var r []string
+	for _, k := range ks {
+		r = append(r, fmt.Sprintf("%d=%d", k, p.Workspaces[k]))
+	}
+	if len(r) == 0 {
+		return "all default"
+	}
+	return strings.Join(r, " ")
+}
+
+// applyPreset sets the brightness of each of the provided workspaces from
+// the preset. If workspaces is nil, a mapping preset replaces the
+// brightness of every workspace.
+func (w *Workspace) applyPreset(p *Preset, workspaces []int) {
+	w.changed = true
+	if p.Brightness != 0 {
+		for _, n := range workspaces {
+			w.profile(n).Brightness = p.Brightness
+		}
+		return
+	}
+	if workspaces == nil {
+		for _, pr := range w.Profiles {
+			pr.Brightness = 0
+		}
+		for n, b := range p.Workspaces {
+			w.profile(n).Brightness = b
+		}
+		return
+	}
+	for _, n := range workspaces {
+		w.profile(n).Brightness = p.Workspaces[n]
+	}
+}
+
+func (w *Workspace) savePreset(o command.Output, d *command.Data) error {
+	p := &Preset{}
+	if d.Has(brightnessArg) {
+		p.Brightness = d.Int(brightnessArg)
+	} else {
+		p.Workspaces = map[int]int{}
+		for _, k := range w.configuredBrightness() {
+			p.Workspaces[k] = w.Profiles[k].Brightness
+		}
+	}
+	if w.Presets == nil {
+		w.Presets = map[string]*Preset{}
+	}
+	w.Presets[d.String(presetArg)] = p
+	w.changed = true
+	return nil
+}
+
+// presetTargets returns the workspaces that the TARGET argument refers to.
+// Single brightness presets apply to the current workspace by default, and
+// mapping presets apply to every workspace.
+func presetTargets(p *Preset, d *command.Data, e env) ([]int, error) {
+	spec := "all"
+	if d.Has(presetTargetArg) {
+		spec = d.String(presetTargetArg)
+	} else if p.Brightness != 0 {
+		c, err := e.currentWorkspace()
+		if err != nil {
+			return nil, err
+		}
+		return []int{c}, nil
+	}
+
+	if spec != "all" {
+		n, err := resolveWorkspaceSpec(spec, e)
+		if err != nil {
+			return nil, err
+		}
+		return []int{n}, nil
+	}
+	if p.Brightness == 0 {
+		return nil, nil
+	}
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	var r []int
+	for i := 0; i < n; i++ {
+		r = append(r, i)
+	}
+	return r, nil
+}
+
+func (w *Workspace) applyNamedPreset(o command.Output, d *command.Data) ([]string, error) {
+	name := d.String(presetArg)
+	p, ok := w.Presets[name]
+	if !ok {
+		return nil, fmt.Errorf("preset %q does not exist", name)
+	}
+	e := newCLIEnv(o, d)
+	wss, err := presetTargets(p, d, e)
+	if err != nil {
+		return nil, err
+	}
+	w.applyPreset(p, wss)
+
+	// Update the monitors in case the current workspace's brightness changed.
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	mcs, err := e.monitors()
+	if err != nil {
+		e.warn(err, "Failed to get monitor codes")
+		return nil, nil
+	}
+	return w.setBrightness(mcs, w.brightness(c)), nil
+}
+
+func (w *Workspace) listPresets(o command.Output, d *command.Data) error {
+	t := w.forDisplay(displayFlag.Get(d))
+	var names []string
+	for name := range t.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o.Stdoutf("%s: %v\n", name, t.Presets[name])
+	}
+	return nil
+}
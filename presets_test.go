@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestApplyPreset(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		profiles   map[int]*Profile
+		p          *Preset
+		workspaces []int
+		want       map[int]*Profile
+	}{
+		{
+			name: "applies brightness to workspaces",
+			profiles: map[int]*Profile{
+				1: {Brightness: 80},
+			},
+			p:          &Preset{Brightness: 55},
+			workspaces: []int{0, 1},
+			want: map[int]*Profile{
+				0: {Brightness: 55},
+				1: {Brightness: 55},
+			},
+		},
+		{
+			name: "replaces every workspace's brightness",
+			profiles: map[int]*Profile{
+				1: {Brightness: 80},
+				2: {Brightness: 30},
+			},
+			p: &Preset{Workspaces: map[int]int{
+				2: 60,
+				3: 20,
+			}},
+			want: map[int]*Profile{
+				1: {},
+				2: {Brightness: 60},
+				3: {Brightness: 20},
+			},
+		},
+		{
+			name: "applies mapping to one workspace",
+			profiles: map[int]*Profile{
+				1: {Brightness: 80},
+				2: {Brightness: 30},
+			},
+			p: &Preset{Workspaces: map[int]int{
+				2: 60,
+			}},
+			workspaces: []int{1},
+			want: map[int]*Profile{
+				1: {},
+				2: {Brightness: 30},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := &Workspace{Profiles: test.profiles}
+			w.applyPreset(test.p, test.workspaces)
+			if diff := cmp.Diff(test.want, w.Profiles, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("applyPreset() produced unexpected profiles (-want, +got):\n%s", diff)
+			}
+			if !w.changed {
+				t.Errorf("applyPreset() didn't mark the workspace as changed")
+			}
+		})
+	}
+}
+
+func TestPresetString(t *testing.T) {
+	for _, test := range []struct {
+		p    *Preset
+		want string
+	}{
+		{&Preset{Brightness: 55}, "55"},
+		{&Preset{Workspaces: map[int]int{3: 20, 0: 90}}, "0=90 3=20"},
+		{&Preset{}, "all default"},
+	} {
+		if got := test.p.String(); got != test.want {
+			t.Errorf("%#v.String() returned %q; want %q", test.p, got, test.want)
+		}
+	}
+}
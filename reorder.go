@@ -0,0 +1,190 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	swapAArg    = "WORKSPACE_A"
+	swapBArg    = "WORKSPACE_B"
+	moveFromArg = "FROM"
+	moveToArg   = "TO"
+)
+
+// remapWorkspaces moves all per-workspace state according to f, which maps
+// a workspace's old index to its new one.
+func (w *Workspace) remapWorkspaces(f func(int) int) {
+	w.Profiles = remapProfiles(w.Profiles, f)
+	w.Prev = remapIndex(w.Prev, f)
+	w.Scratch = remapIndex(w.Scratch, f)
+	w.ScratchReturn = f(w.ScratchReturn)
+	remapPresets(w.Presets, f)
+	w.PresentationWorkspace = remapIndex(w.PresentationWorkspace, f)
+	// The windows aren't moved back by undo or `ws present stop`, so the
+	// saved settings must follow the windows too.
+	w.Journal.remapWorkspaces(f)
+	if p := w.Presentation; p != nil {
+		p.Return = f(p.Return)
+		p.Settings.remapWorkspaces(f)
+		p.Journal.remapWorkspaces(f)
+	}
+	w.changed = true
+}
+
+// remapWorkspaces moves all per-workspace settings according to f.
+func (s *Settings) remapWorkspaces(f func(int) int) {
+	if s == nil {
+		return
+	}
+	s.Profiles = remapProfiles(s.Profiles, f)
+	s.Scratch = remapIndex(s.Scratch, f)
+	remapPresets(s.Presets, f)
+	s.PresentationWorkspace = remapIndex(s.PresentationWorkspace, f)
+}
+
+// remapWorkspaces moves the per-workspace settings of every journal entry
+// according to f.
+func (j *Journal) remapWorkspaces(f func(int) int) {
+	if j == nil {
+		return
+	}
+	for _, s := range j.Undo {
+		s.remapWorkspaces(f)
+	}
+	for _, s := range j.Redo {
+		s.remapWorkspaces(f)
+	}
+}
+
+func remapProfiles(ps map[int]*Profile, f func(int) int) map[int]*Profile {
+	if ps == nil {
+		return nil
+	}
+	r := map[int]*Profile{}
+	for k, v := range ps {
+		r[f(k)] = v
+	}
+	return r
+}
+
+// remapPresets moves the workspaces of mapping presets according to f.
+func remapPresets(ps map[string]*Preset, f func(int) int) {
+	for _, p := range ps {
+		if p.Workspaces == nil {
+			continue
+		}
+		m := map[int]int{}
+		for k, v := range p.Workspaces {
+			m[f(k)] = v
+		}
+		p.Workspaces = m
+	}
+}
+
+func remapIndex(n *int, f func(int) int) *int {
+	if n == nil {
+		return nil
+	}
+	m := f(*n)
+	return &m
+}
+
+// reorder moves all windows and per-workspace state according to f, and then
+// moves to the workspace that now has the current workspace's contents.
+func (w *Workspace) reorder(f func(int) int, e env) ([]string, error) {
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	lines, err := e.windows()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := parseWindows(lines)
+	if err != nil {
+		return nil, err
+	}
+	var r []string
+	for _, win := range windows {
+		if win.desktop < 0 {
+			continue
+		}
+		if to := f(win.desktop); to != win.desktop {
+			r = append(r, fmt.Sprintf("wmctrl -i -r %s -t %d", win.id, to))
+		}
+	}
+	w.remapWorkspaces(f)
+
+	// The brightness moved with the current workspace, so only the
+	// workspace needs to change.
+	if nc := f(c); nc != c {
+		w.switched = &nc
+		r = append(r, fmt.Sprintf("wmctrl -s %d", nc))
+	}
+	return r, nil
+}
+
+// resolveWorkspacePair resolves two workspace arguments and verifies that
+// both workspaces exist.
+func resolveWorkspacePair(aArg, bArg string, d *command.Data, e env) (int, int, error) {
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return 0, 0, err
+	}
+	var r []int
+	for _, arg := range []string{aArg, bArg} {
+		ws, err := resolveWorkspaceSpec(d.String(arg), e)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ws >= n {
+			return 0, 0, &WorkspaceNotFoundError{Workspace: ws, NumWorkspaces: n}
+		}
+		r = append(r, ws)
+	}
+	return r[0], r[1], nil
+}
+
+func (w *Workspace) swap(o command.Output, d *command.Data) ([]string, error) {
+	e := newCLIEnv(o, d)
+	a, b, err := resolveWorkspacePair(swapAArg, swapBArg, d, e)
+	if err != nil {
+		return nil, err
+	}
+	if a == b {
+		return nil, nil
+	}
+	return w.reorder(func(i int) int {
+		switch i {
+		case a:
+			return b
+		case b:
+			return a
+		}
+		return i
+	}, e)
+}
+
+func (w *Workspace) moveWorkspace(o command.Output, d *command.Data) ([]string, error) {
+	e := newCLIEnv(o, d)
+	from, to, err := resolveWorkspacePair(moveFromArg, moveToArg, d, e)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, nil
+	}
+	return w.reorder(func(i int) int {
+		switch {
+		case i == from:
+			return to
+		case from < to && i > from && i <= to:
+			return i - 1
+		case from > to && i >= to && i < from:
+			return i + 1
+		}
+		return i
+	}, e)
+}
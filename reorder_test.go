@@ -0,0 +1,121 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRemapWorkspaces(t *testing.T) {
+	swap12 := func(i int) int {
+		switch i {
+		case 1:
+			return 2
+		case 2:
+			return 1
+		}
+		return i
+	}
+
+	for _, test := range []struct {
+		name string
+		w    *Workspace
+		want *Workspace
+	}{
+		{
+			name: "remaps workspace state",
+			w: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 10},
+					2: {Brightness: 40},
+				},
+				Scratch:       intPtr(2),
+				ScratchReturn: 2,
+				Presets: map[string]*Preset{
+					"day":     {Workspaces: map[int]int{1: 90, 3: 70}},
+					"reading": {Brightness: 55},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 10},
+					1: {Brightness: 40},
+				},
+				Scratch:       intPtr(1),
+				ScratchReturn: 1,
+				Presets: map[string]*Preset{
+					"day":     {Workspaces: map[int]int{2: 90, 3: 70}},
+					"reading": {Brightness: 55},
+				},
+			},
+		},
+		{
+			name: "remaps journaled settings",
+			w: &Workspace{
+				Journal: &Journal{
+					Undo: []*Settings{
+						{},
+						{Profiles: map[int]*Profile{1: {Brightness: 40}}, Presets: map[string]*Preset{"night": {Workspaces: map[int]int{2: 20}}}},
+					},
+					Redo: []*Settings{
+						{Profiles: map[int]*Profile{2: {Brightness: 60}}, Scratch: intPtr(1)},
+					},
+				},
+			},
+			want: &Workspace{
+				Journal: &Journal{
+					Undo: []*Settings{
+						{},
+						{Profiles: map[int]*Profile{2: {Brightness: 40}}, Presets: map[string]*Preset{"night": {Workspaces: map[int]int{1: 20}}}},
+					},
+					Redo: []*Settings{
+						{Profiles: map[int]*Profile{1: {Brightness: 60}}, Scratch: intPtr(2)},
+					},
+				},
+			},
+		},
+		{
+			name: "remaps presentation",
+			w: &Workspace{
+				PresentationWorkspace: intPtr(1),
+				Presentation: &Presentation{
+					Settings: &Settings{
+						Profiles:              map[int]*Profile{2: {Brightness: 40}},
+						PresentationWorkspace: intPtr(1),
+					},
+					Return: 2,
+					Journal: &Journal{
+						Undo: []*Settings{
+							{Profiles: map[int]*Profile{1: {Brightness: 60}}},
+						},
+					},
+				},
+			},
+			want: &Workspace{
+				PresentationWorkspace: intPtr(2),
+				Presentation: &Presentation{
+					Settings: &Settings{
+						Profiles:              map[int]*Profile{1: {Brightness: 40}},
+						PresentationWorkspace: intPtr(2),
+					},
+					Return: 1,
+					Journal: &Journal{
+						Undo: []*Settings{
+							{Profiles: map[int]*Profile{2: {Brightness: 60}}},
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.w.remapWorkspaces(swap12)
+			if diff := cmp.Diff(test.want, test.w, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+				t.Errorf("remapWorkspaces() produced diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
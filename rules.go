@@ -0,0 +1,214 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	patternArg   = "PATTERN"
+	ruleIndexArg = "RULE"
+
+	rulePollInterval = time.Second
+)
+
+var (
+	ruleTitleFlag = command.BoolFlag("title", 't', "Match the window title instead of the window class")
+
+	// activeWindow prints the class and then the title of the focused window.
+	activeWindow = &command.BashCommand[[]string]{
+		ArgName:  "activeWindow",
+		Contents: []string{`id="$(xdotool getactivewindow)" && xdotool getwindowclassname "$id" && xdotool getwindowname "$id"`},
+	}
+)
+
+// BrightnessRule overrides the workspace brightness while a matching window
+// is focused.
+type BrightnessRule struct {
+	// Pattern is the regular expression that the window class (or title) must
+	// match.
+	Pattern string
+	// Title is whether Pattern is matched against the window title instead of
+	// the window class.
+	Title      bool `json:",omitempty"`
+	Brightness int
+}
+
+func (r *BrightnessRule) String() string {
+	field := "class"
+	if r.Title {
+		field = "title"
+	}
+	return fmt.Sprintf("%s %q -> %d", field, r.Pattern, r.Brightness)
+}
+
+// matchRule returns the first rule that matches the window, if any.
+func (w *Workspace) matchRule(class, title string) (*BrightnessRule, error) {
+	for _, r := range w.Rules {
+		s := class
+		if r.Title {
+			s = title
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", r.Pattern, err)
+		}
+		if re.MatchString(s) {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (w *Workspace) addRule(o command.Output, d *command.Data) error {
+	pattern := d.String(patternArg)
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	w.Rules = append(w.Rules, &BrightnessRule{
+		Pattern:    pattern,
+		Title:      ruleTitleFlag.Get(d),
+		Brightness: d.Int(brightnessArg),
+	})
+	w.changed = true
+	return nil
+}
+
+func (w *Workspace) removeRule(o command.Output, d *command.Data) error {
+	i := d.Int(ruleIndexArg)
+	if i >= len(w.Rules) {
+		return fmt.Errorf("rule %d does not exist (only %d rules)", i, len(w.Rules))
+	}
+	w.Rules = append(w.Rules[:i], w.Rules[i+1:]...)
+	w.changed = true
+	return nil
+}
+
+func (w *Workspace) listRules(o command.Output, d *command.Data) error {
+	for i, r := range w.Rules {
+		o.Stdoutf("%2d: %v\n", i, r)
+	}
+	return nil
+}
+
+// ruleWatcher applies brightness rules when a matching window is focused and
+// restores the current workspace's brightness when focus leaves it.
+type ruleWatcher struct {
+	client *Client
+	// applied is the rule brightness that is currently applied, if any.
+	applied *int
+	// workspace and base are the current workspace and its brightness when
+	// the rule was applied. Switching workspaces or changing the workspace's
+	// brightness overwrites the rule's brightness, so the rule must be
+	// applied again when either changes.
+	workspace, base int
+}
+
+// poll checks the focused window once and updates the brightness if
+// necessary.
+func (rw *ruleWatcher) poll(ctx context.Context) error {
+	e := rw.client.env(ctx)
+	// Rules may have been changed by other ws processes.
+	if err := rw.client.Workspace.reload(); err != nil {
+		return err
+	}
+
+	// xdotool fails if no window is focused, which is the same as focusing
+	// a window that doesn't match any rule.
+	var class, title string
+	if lines, err := e.query(activeWindow.Contents); err == nil && len(lines) >= 2 {
+		class, title = lines[0], lines[1]
+	}
+	r, err := rw.client.Workspace.matchRule(class, title)
+	if err != nil {
+		return err
+	}
+
+	if r == nil {
+		return rw.restore(e)
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return err
+	}
+	base := rw.client.state().brightness(c)
+	if rw.applied != nil && *rw.applied == r.Brightness && rw.workspace == c && rw.base == base {
+		return nil
+	}
+	if err := rw.setBrightness(e, r.Brightness); err != nil {
+		return err
+	}
+	b := r.Brightness
+	rw.applied, rw.workspace, rw.base = &b, c, base
+	return rw.record(&b)
+}
+
+// restore sets the current workspace's brightness if a rule is applied.
+func (rw *ruleWatcher) restore(e *runnerEnv) error {
+	if rw.applied == nil {
+		return nil
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := rw.setBrightness(e, rw.client.state().brightness(c)); err != nil {
+		return err
+	}
+	rw.applied = nil
+	return rw.record(nil)
+}
+
+// record saves the applied rule brightness so that other ws processes (e.g.
+// the idle dimmer) restore it rather than the workspace's brightness.
+func (rw *ruleWatcher) record(b *int) error {
+	return rw.client.Workspace.update(rw.client.Display, func(*int) error {
+		s := rw.client.state()
+		s.RuleBrightness = b
+		s.changed = true
+		return nil
+	})
+}
+
+// run polls until ctx is done, and then restores the workspace's
+// brightness if a rule is applied.
+func (rw *ruleWatcher) run(ctx context.Context) error {
+	for {
+		if err := rw.poll(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return rw.restore(rw.client.env(context.Background()))
+		case <-time.After(rulePollInterval):
+		}
+	}
+}
+
+func (rw *ruleWatcher) setBrightness(e *runnerEnv, b int) error {
+	mcs, err := e.monitors()
+	if err != nil {
+		return err
+	}
+	return e.runAll(rw.client.state().setBrightness(mcs, b))
+}
+
+func (w *Workspace) watchRules(o command.Output, d *command.Data) error {
+	if _, err := lookPath("xdotool"); err != nil {
+		return o.Err(fmt.Errorf("xdotool is required to watch the focused window: %v", err))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	rw := &ruleWatcher{client: &Client{Workspace: w, Display: displayFlag.Get(d)}}
+	if err := rw.run(ctx); err != nil {
+		return o.Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRuleWatcher(t *testing.T) {
+	aw := query(activeWindow)
+
+	w := &Workspace{
+		Profiles: map[int]*Profile{
+			1: {Brightness: 40},
+		},
+		Rules: []*BrightnessRule{
+			{Pattern: "^mpv$", Brightness: 100},
+			{Pattern: "YouTube", Title: true, Brightness: 90},
+		},
+	}
+	fr := &fakeRunner{
+		responses: map[string][]string{
+			dq: numberedDesktops(4, 1),
+			lm: {"DP-1"},
+		},
+		errs: map[string]error{},
+	}
+	rw := &ruleWatcher{client: &Client{Workspace: w, Runner: fr}}
+
+	for _, step := range []struct {
+		name      string
+		window    []string
+		windowErr error
+		current   int
+		// brightness is the brightness of workspace 1, if changed.
+		brightness int
+		rules      []*BrightnessRule
+		wantRun    []string
+		wantErr    error
+		wantRule   *int
+	}{
+		{
+			name:    "does nothing for unmatched window",
+			window:  []string{"terminal", "bash"},
+			current: 1,
+			wantRun: []string{aw},
+		},
+		{
+			name:     "applies class rule",
+			window:   []string{"mpv", "movie.mkv - mpv"},
+			current:  1,
+			wantRun:  []string{aw, dq, lm, "xrandr --output DP-1 --brightness 1.00"},
+			wantRule: intPtr(100),
+		},
+		{
+			name:     "does nothing if rule is already applied",
+			window:   []string{"mpv", "other.mkv - mpv"},
+			current:  1,
+			wantRun:  []string{aw, dq},
+			wantRule: intPtr(100),
+		},
+		{
+			name:     "applies rule again after workspace switch",
+			window:   []string{"mpv", "other.mkv - mpv"},
+			current:  2,
+			wantRun:  []string{aw, dq, lm, "xrandr --output DP-1 --brightness 1.00"},
+			wantRule: intPtr(100),
+		},
+		{
+			name:     "applies title rule",
+			window:   []string{"firefox", "Cats - YouTube"},
+			current:  1,
+			wantRun:  []string{aw, dq, lm, "xrandr --output DP-1 --brightness 0.90"},
+			wantRule: intPtr(90),
+		},
+		{
+			name:       "applies rule again after brightness change",
+			window:     []string{"firefox", "Cats - YouTube"},
+			current:    1,
+			brightness: 30,
+			wantRun:    []string{aw, dq, lm, "xrandr --output DP-1 --brightness 0.90"},
+			wantRule:   intPtr(90),
+		},
+		{
+			name:    "restores workspace brightness when focus leaves",
+			window:  []string{"terminal", "bash"},
+			current: 1,
+			wantRun: []string{aw, dq, lm, "xrandr --output DP-1 --brightness 0.30"},
+		},
+		{
+			name:     "applies rule again",
+			window:   []string{"mpv", "movie.mkv - mpv"},
+			current:  1,
+			wantRun:  []string{aw, dq, lm, "xrandr --output DP-1 --brightness 1.00"},
+			wantRule: intPtr(100),
+		},
+		{
+			name:      "restores workspace brightness when no window is focused",
+			windowErr: fmt.Errorf("no active window"),
+			current:   1,
+			wantRun:   []string{aw, dq, lm, "xrandr --output DP-1 --brightness 0.30"},
+		},
+		{
+			name:    "fails for invalid saved pattern",
+			window:  []string{"mpv", "movie.mkv - mpv"},
+			current: 1,
+			rules:   []*BrightnessRule{{Pattern: "mpv(", Brightness: 100}},
+			wantRun: []string{aw},
+			wantErr: fmt.Errorf("invalid pattern \"mpv(\": error parsing regexp: missing closing ): `mpv(`"),
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			fr.got = nil
+			fr.responses[aw] = step.window
+			delete(fr.errs, aw)
+			if step.windowErr != nil {
+				fr.errs[aw] = step.windowErr
+			}
+			fr.responses[dq] = numberedDesktops(4, step.current)
+			if step.brightness != 0 {
+				w.Profiles[1].Brightness = step.brightness
+			}
+			if step.rules != nil {
+				w.Rules = step.rules
+			}
+
+			err := rw.poll(context.Background())
+			if diff := cmp.Diff(step.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("poll() returned unexpected error (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(step.wantRun, fr.got); diff != "" {
+				t.Errorf("poll() ran unexpected commands (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(step.wantRule, w.RuleBrightness); diff != "" {
+				t.Errorf("poll() recorded unexpected rule brightness (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// stateVersion is the version of the saved Workspace state. It must be
+// incremented (and a migration added) whenever existing saved state needs to
+// be converted. Adding a new field doesn't require a new version.
+//
+// Version history:
+//
+//	0: Unversioned. Brightness is a map from workspace to brightness.
+//	1: Brightness is moved into Profiles.
+const stateVersion = 1
+
+// migrations upgrade the raw saved state from version i to version i+1.
+var migrations = []func(map[string]json.RawMessage) error{
+	migrateV0,
+}
+
+// migrateV0 lifts Brightness[n] into Profiles[n].Brightness.
+func migrateV0(m map[string]json.RawMessage) error {
+	b, ok := m["Brightness"]
+	if !ok {
+		return nil
+	}
+	delete(m, "Brightness")
+
+	var brightness map[int]int
+	if err := json.Unmarshal(b, &brightness); err != nil {
+		return fmt.Errorf("failed to parse Brightness: %v", err)
+	}
+	if brightness == nil {
+		return nil
+	}
+	profiles := map[int]map[string]int{}
+	for k, v := range brightness {
+		profiles[k] = map[string]int{"Brightness": v}
+	}
+	p, err := json.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Profiles: %v", err)
+	}
+	m["Profiles"] = p
+	return nil
+}
+
+// workspaceJSON has the same fields as Workspace, but uses the default JSON
+// encoding.
+type workspaceJSON Workspace
+
+type versionedWorkspace struct {
+	Version int
+	*workspaceJSON
+}
+
+// MarshalJSON saves the workspace along with the current state version.
+func (w *Workspace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&versionedWorkspace{stateVersion, (*workspaceJSON)(w)})
+}
+
+// UnmarshalJSON loads the workspace, migrating it from older state versions
+// if necessary.
+func (w *Workspace) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	var version int
+	if v, ok := m["Version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return fmt.Errorf("failed to parse state version: %v", err)
+		}
+		delete(m, "Version")
+	}
+	if version < 0 || version > stateVersion {
+		return fmt.Errorf("unsupported state version %d (latest supported version is %d)", version, stateVersion)
+	}
+	for ; version < stateVersion; version++ {
+		if err := migrations[version](m); err != nil {
+			return fmt.Errorf("failed to migrate state from version %d: %v", version, err)
+		}
+	}
+
+	mb, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(mb, (*workspaceJSON)(w))
+}
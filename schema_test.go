@@ -0,0 +1,136 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStateMigrations(t *testing.T) {
+	for _, test := range []struct {
+		file string
+		want *Workspace
+	}{
+		{
+			file: "v0-original.json",
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 50},
+					3: {Brightness: 120},
+				},
+			},
+		},
+		{
+			file: "v0-no-brightness.json",
+			want: &Workspace{
+				Prev: intPtr(2),
+			},
+		},
+		{
+			file: "v0.json",
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 50},
+				},
+				SkipEmpty:     true,
+				Scratch:       intPtr(4),
+				ScratchReturn: 1,
+			},
+		},
+		{
+			file: "v1.json",
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 50},
+				},
+				SkipEmpty:     true,
+				Scratch:       intPtr(4),
+				ScratchReturn: 1,
+			},
+		},
+	} {
+		t.Run(test.file, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", "state", test.file))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			w := &Workspace{}
+			if err := json.Unmarshal(b, w); err != nil {
+				t.Fatalf("json.Unmarshal(%s) returned error: %v", test.file, err)
+			}
+			if diff := cmp.Diff(test.want, w, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+				t.Errorf("json.Unmarshal(%s) produced incorrect workspace (-want, +got):\n%s", test.file, diff)
+			}
+
+			// Saving the migrated state should produce the latest version.
+			saved, err := json.Marshal(w)
+			if err != nil {
+				t.Fatalf("json.Marshal() returned error: %v", err)
+			}
+			var v struct{ Version int }
+			if err := json.Unmarshal(saved, &v); err != nil {
+				t.Fatalf("failed to parse saved version: %v", err)
+			}
+			if v.Version != stateVersion {
+				t.Errorf("json.Marshal() saved version %d; want %d", v.Version, stateVersion)
+			}
+			reloaded := &Workspace{}
+			if err := json.Unmarshal(saved, reloaded); err != nil {
+				t.Fatalf("json.Unmarshal(saved) returned error: %v", err)
+			}
+			if diff := cmp.Diff(w, reloaded, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+				t.Errorf("Reloading saved state produced a different workspace (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStateFixturesForEveryVersion(t *testing.T) {
+	if len(migrations) != stateVersion {
+		t.Errorf("len(migrations) is %d; want one migration per version (%d)", len(migrations), stateVersion)
+	}
+	for v := 0; v <= stateVersion; v++ {
+		if _, err := os.Stat(filepath.Join("testdata", "state", fmt.Sprintf("v%d.json", v))); err != nil {
+			t.Errorf("No fixture for state version %d: %v", v, err)
+		}
+	}
+}
+
+func TestStateVersionErrors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		blob    string
+		wantErr error
+	}{
+		{
+			name:    "newer version",
+			blob:    fmt.Sprintf(`{"Version":%d,"Prev":1}`, stateVersion+1),
+			wantErr: fmt.Errorf("unsupported state version %d (latest supported version is %d)", stateVersion+1, stateVersion),
+		},
+		{
+			name:    "invalid version",
+			blob:    `{"Version":"one"}`,
+			wantErr: fmt.Errorf("failed to parse state version: json: cannot unmarshal string into Go value of type int"),
+		},
+		{
+			name:    "invalid v0 brightness",
+			blob:    `{"Brightness":"dim"}`,
+			wantErr: fmt.Errorf("failed to migrate state from version 0: failed to parse Brightness: json: cannot unmarshal string into Go value of type map[int]int"),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := json.Unmarshal([]byte(test.blob), &Workspace{})
+			if diff := cmp.Diff(test.wantErr, err, cmpErr); diff != "" {
+				t.Errorf("json.Unmarshal(%s) returned incorrect error (-want, +got):\n%s", test.blob, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package workspace
+
+import (
+	"github.com/leep-frog/command"
+)
+
+// scratchWorkspace returns the index of the scratch workspace.
+func (w *Workspace) scratchWorkspace(e env) (int, error) {
+	if w.Scratch != nil {
+		return *w.Scratch, nil
+	}
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return 0, err
+	}
+	return n - 1, nil
+}
+
+func (w *Workspace) toggleScratch(o command.Output, d *command.Data) ([]string, error) {
+	e := newCLIEnv(o, d)
+	s, err := w.scratchWorkspace(e)
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if c != s {
+		w.ScratchReturn = c
+		w.changed = true
+		return w.switchTo(s, e), nil
+	}
+	if w.ScratchReturn == s {
+		return nil, nil
+	}
+	return w.switchTo(w.ScratchReturn, e), nil
+}
+
+func (w *Workspace) setScratch(o command.Output, d *command.Data) error {
+	s, err := resolveWorkspace(d, newCLIEnv(o, d))
+	if err != nil {
+		return err
+	}
+	w.Scratch = &s
+	if d.Has(brightnessArg) {
+		w.profile(s).Brightness = d.Int(brightnessArg)
+	}
+	w.changed = true
+	return nil
+}
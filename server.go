@@ -0,0 +1,228 @@
+package workspace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	socketName = "leep-frog-workspace.sock"
+)
+
+var (
+	socketFlag = command.Flag[string]("socket", 's', "Path of the Unix socket to listen on", command.Default(defaultSocketPath()))
+)
+
+func defaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, socketName)
+}
+
+// serverRequest is a single request sent to `ws serve`. Each request is a
+// JSON object on its own line.
+type serverRequest struct {
+	// Command is one of "switch", "relative", "back", "brightness-get",
+	// "brightness-set", or "list".
+	Command string `json:"command"`
+	// Workspace is the workspace for "switch", "brightness-get", and
+	// "brightness-set". The brightness commands default to the current
+	// workspace.
+	Workspace *int `json:"workspace,omitempty"`
+	// Offset is the number of workspaces to move for "relative".
+	Offset int `json:"offset,omitempty"`
+	// Brightness is the brightness percentage for "brightness-set".
+	Brightness int `json:"brightness,omitempty"`
+}
+
+// serverResponse is the response to a serverRequest, sent as a JSON object
+// on its own line.
+type serverResponse struct {
+	OK         bool         `json:"ok"`
+	Error      string       `json:"error,omitempty"`
+	Brightness int          `json:"brightness,omitempty"`
+	Workspaces []*listEntry `json:"workspaces,omitempty"`
+}
+
+// server handles requests for a Client. Requests are handled one at a time.
+type server struct {
+	mu     sync.Mutex
+	client *Client
+}
+
+// serve handles connections on l until ctx is done.
+func serve(ctx context.Context, l net.Listener, c *Client) error {
+	s := &server{client: c}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handleConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *server) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var resp *serverResponse
+		req := &serverRequest{}
+		if err := json.Unmarshal(scanner.Bytes(), req); err != nil {
+			resp = &serverResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			resp = s.handle(ctx, req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *server) handle(ctx context.Context, req *serverRequest) *serverResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &serverResponse{}
+	var err error
+	switch req.Command {
+	case "switch":
+		if req.Workspace == nil {
+			err = fmt.Errorf("switch requires a workspace")
+		} else {
+			err = s.client.Switch(ctx, *req.Workspace)
+		}
+	case "relative":
+		err = s.client.Relative(ctx, req.Offset)
+	case "back":
+		err = s.client.Back(ctx)
+	case "brightness-get":
+		resp.Brightness, err = s.brightness(ctx, req.Workspace)
+	case "brightness-set":
+		var ws int
+		if ws, err = s.workspaceOrCurrent(ctx, req.Workspace); err == nil {
+			err = s.client.SetBrightness(ctx, ws, req.Brightness)
+		}
+	case "list":
+		resp.Workspaces, err = s.list(ctx)
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+	if err != nil {
+		return &serverResponse{Error: err.Error()}
+	}
+	resp.OK = true
+	return resp
+}
+
+func (s *server) workspaceOrCurrent(ctx context.Context, ws *int) (int, error) {
+	if ws != nil {
+		return *ws, nil
+	}
+	return s.client.env(ctx).currentWorkspace()
+}
+
+func (s *server) brightness(ctx context.Context, ws *int) (int, error) {
+	n, err := s.workspaceOrCurrent(ctx, ws)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.client.Workspace.reload(); err != nil {
+		return 0, err
+	}
+	return s.client.state().brightness(n), nil
+}
+
+func (s *server) list(ctx context.Context) ([]*listEntry, error) {
+	e := s.client.env(ctx)
+	desktops, err := e.desktops()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := windowCounts(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.client.Workspace.reload(); err != nil {
+		return nil, err
+	}
+	return s.client.state().listEntries(desktops, windows), nil
+}
+
+// listen listens on the Unix socket at path, replacing the socket if it was
+// left behind by a ws serve process that is no longer running.
+func listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("ws serve is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to check socket: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+	return l, nil
+}
+
+func (w *Workspace) serve(o command.Output, d *command.Data) error {
+	path := socketFlag.Get(d)
+	l, err := listen(path)
+	if err != nil {
+		return o.Err(err)
+	}
+	// Closing the listener on a signal also removes the socket file.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	o.Stdoutf("Listening on %s\n", path)
+	if err := serve(ctx, l, &Client{Workspace: w, Display: displayFlag.Get(d)}); err != nil {
+		return o.Err(err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package workspace
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	s := &store{filepath.Join(dir, "state.json")}
+	w := &Workspace{store: s}
+	fr := &fakeRunner{responses: map[string][]string{
+		dq: desktopLines(1, "main", "web", "chat", "music"),
+		lm: {"DP-1"},
+		wq: {
+			"0x03a00003  1 host Terminal",
+			"0x03a00004  3 host Music",
+		},
+	}}
+
+	l, err := listen(filepath.Join(dir, "ws.sock"))
+	if err != nil {
+		t.Fatalf("listen() returned error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- serve(ctx, l, &Client{Workspace: w, Runner: fr})
+	}()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("serve() returned error: %v", err)
+		}
+	}()
+
+	conn, err := net.Dial("unix", filepath.Join(dir, "ws.sock"))
+	if err != nil {
+		t.Fatalf("failed to connect to server: %v", err)
+	}
+	defer conn.Close()
+	responses := bufio.NewScanner(conn)
+
+	for _, step := range []struct {
+		request string
+		want    string
+		wantRun []string
+	}{
+		{
+			request: `{"command":"switch","workspace":2}`,
+			want:    `{"ok":true}`,
+			wantRun: []string{dq, lm, "wmctrl -s 2", "xrandr --output DP-1 --brightness 1.00"},
+		},
+		{
+			// wmctrl still reports workspace 1, but the pending switch is used.
+			request: `{"command":"relative","offset":1}`,
+			want:    `{"ok":true}`,
+			wantRun: []string{dq, lm, "wmctrl -s 3", "xrandr --output DP-1 --brightness 1.00"},
+		},
+		{
+			request: `{"command":"brightness-set","workspace":0,"brightness":80}`,
+			want:    `{"ok":true}`,
+			wantRun: []string{dq},
+		},
+		{
+			request: `{"command":"brightness-get","workspace":0}`,
+			want:    `{"ok":true,"brightness":80}`,
+		},
+		{
+			request: `{"command":"brightness-get"}`,
+			want:    `{"ok":true,"brightness":100}`,
+			wantRun: []string{dq},
+		},
+		{
+			request: `{"command":"brightness-set","workspace":0,"brightness":300}`,
+			want:    `{"ok":false,"error":"brightness 300 is not between 5 and 250"}`,
+			wantRun: []string{dq},
+		},
+		{
+			request: `{"command":"brightness-set","workspace":7,"brightness":80}`,
+			want:    `{"ok":false,"error":"workspace 7 does not exist (only 4 workspaces)"}`,
+			wantRun: []string{dq},
+		},
+		{
+			request: `{"command":"switch","workspace":9}`,
+			want:    `{"ok":false,"error":"workspace 9 does not exist (only 4 workspaces)"}`,
+			wantRun: []string{dq},
+		},
+		{
+			request: `{"command":"switch"}`,
+			want:    `{"ok":false,"error":"switch requires a workspace"}`,
+		},
+		{
+			request: `{"command":"fly"}`,
+			want:    `{"ok":false,"error":"unknown command \"fly\""}`,
+		},
+		{
+			request: `{"command":`,
+			want:    `{"ok":false,"error":"invalid request: unexpected end of JSON input"}`,
+		},
+		{
+			request: `{"command":"list"}`,
+			want: `{"ok":true,"workspaces":[` + `{"index":0,"name":"main","current":false,"previous":false,"windows":0,"brightness":80,"defaultBrightness":false},` +
+				`{"index":1,"name":"web","current":true,"previous":false,"windows":1,"brightness":100,"defaultBrightness":true},` +
+				`{"index":2,"name":"chat","current":false,"previous":true,"windows":0,"brightness":100,"defaultBrightness":true},` +
+				`{"index":3,"name":"music","current":false,"previous":false,"windows":1,"brightness":100,"defaultBrightness":true}]}`,
+			wantRun: []string{dq, wq},
+		},
+	} {
+		fr.got = nil
+		if _, err := fmt.Fprintln(conn, step.request); err != nil {
+			t.Fatalf("failed to send request %s: %v", step.request, err)
+		}
+		if !responses.Scan() {
+			t.Fatalf("no response for request %s: %v", step.request, responses.Err())
+		}
+		if diff := cmp.Diff(step.want, responses.Text()); diff != "" {
+			t.Errorf("Request %s produced incorrect response (-want, +got):\n%s", step.request, diff)
+		}
+		if diff := cmp.Diff(step.wantRun, fr.got); diff != "" {
+			t.Errorf("Request %s ran unexpected commands (-want, +got):\n%s", step.request, diff)
+		}
+	}
+
+	// The state should be saved where the CLI can read it.
+	ss, ok, err := s.load()
+	if err != nil || !ok {
+		t.Fatalf("load() returned (%v, %v); want (true, nil)", ok, err)
+	}
+	want := &Workspace{
+		Prev: intPtr(2),
+		Profiles: map[int]*Profile{
+			0: {Brightness: 80},
+		},
+		Journal: &Journal{
+			Undo: []*Settings{{}},
+		},
+	}
+	if diff := cmp.Diff(want, ss.Workspace, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+		t.Errorf("Server saved unexpected state (-want, +got):\n%s", diff)
+	}
+}
+
+func TestListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ws.sock")
+	l, err := listen(path)
+	if err != nil {
+		t.Fatalf("listen() returned error: %v", err)
+	}
+
+	wantErr := fmt.Errorf("ws serve is already listening on %s", path)
+	if _, err := listen(path); cmp.Diff(wantErr, err, cmpErr) != "" {
+		t.Errorf("listen() for a socket in use returned error %v; want %v", err, wantErr)
+	}
+
+	// Leave the socket file behind, like a process that was killed.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = listen(path)
+	if err != nil {
+		t.Fatalf("listen() for a stale socket returned error: %v", err)
+	}
+	l.Close()
+}
@@ -0,0 +1,171 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	textFormat   = "text"
+	jsonFormat   = "json"
+	waybarFormat = "waybar"
+	i3barFormat  = "i3bar"
+)
+
+var (
+	statusFormats = []string{textFormat, jsonFormat, waybarFormat, i3barFormat}
+
+	statusFormatFlag = command.Flag[string]("format", 'f', "Output format", command.Default(textFormat), command.SimpleCompleter[string](statusFormats...), command.InList(statusFormats...))
+	statusFollowFlag = command.BoolFlag("follow", 'F', "Print a new status whenever the workspace or brightness changes")
+
+	// statusPollInterval is how often the desktops are checked when following.
+	statusPollInterval = 500 * time.Millisecond
+)
+
+type workspaceStatus struct {
+	Index      int    `json:"index"`
+	Name       string `json:"name"`
+	Current    bool   `json:"current"`
+	Brightness int    `json:"brightness"`
+}
+
+func (ws *workspaceStatus) String() string {
+	return fmt.Sprintf("%d:%s(%d%%)", ws.Index, ws.Name, ws.Brightness)
+}
+
+func (w *Workspace) statuses(desktops []*desktop) []*workspaceStatus {
+	var r []*workspaceStatus
+	for _, d := range desktops {
+		r = append(r, &workspaceStatus{
+			Index:      d.index,
+			Name:       d.name,
+			Current:    d.current,
+			Brightness: w.brightness(d.index),
+		})
+	}
+	return r
+}
+
+type waybarStatus struct {
+	Text       string `json:"text"`
+	Alt        string `json:"alt"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+type i3barBlock struct {
+	Name     string `json:"name"`
+	Instance string `json:"instance"`
+	FullText string `json:"full_text"`
+}
+
+func formatStatus(format string, statuses []*workspaceStatus) (string, error) {
+	switch format {
+	case textFormat:
+		var parts []string
+		for _, s := range statuses {
+			if s.Current {
+				parts = append(parts, fmt.Sprintf("[%v]", s))
+			} else {
+				parts = append(parts, s.String())
+			}
+		}
+		return strings.Join(parts, " "), nil
+	case jsonFormat:
+		return marshalJSON(statuses)
+	case waybarFormat:
+		var tooltip []string
+		wb := &waybarStatus{}
+		for _, s := range statuses {
+			tooltip = append(tooltip, s.String())
+			if s.Current {
+				wb.Text = fmt.Sprintf("%d:%s", s.Index, s.Name)
+				wb.Alt = s.Name
+				wb.Class = fmt.Sprintf("workspace-%d", s.Index)
+				wb.Percentage = s.Brightness
+			}
+		}
+		wb.Tooltip = strings.Join(tooltip, "\n")
+		return marshalJSON(wb)
+	case i3barFormat:
+		var blocks []*i3barBlock
+		for _, s := range statuses {
+			// i3bar's urgent field is for workspaces that need attention, so
+			// the current workspace is marked the same way as the text format.
+			text := fmt.Sprintf("%d:%s %d%%", s.Index, s.Name, s.Brightness)
+			if s.Current {
+				text = fmt.Sprintf("[%s]", text)
+			}
+			blocks = append(blocks, &i3barBlock{
+				Name:     "workspace",
+				Instance: fmt.Sprintf("%d", s.Index),
+				FullText: text,
+			})
+		}
+		return marshalJSON(blocks)
+	}
+	return "", fmt.Errorf("unknown status format: %q", format)
+}
+
+func marshalJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	return string(b), nil
+}
+
+func (w *Workspace) status(o command.Output, d *command.Data) error {
+	format, display := statusFormatFlag.Get(d), displayFlag.Get(d)
+	if !statusFollowFlag.Get(d) {
+		s, err := w.forDisplay(display).formatDesktops(format, listDesktops.Get(d))
+		if err != nil {
+			return o.Err(err)
+		}
+		o.Stdoutln(s)
+		return nil
+	}
+
+	// i3bar expects a header followed by an infinite JSON array.
+	if format == i3barFormat {
+		o.Stdoutln(`{"version":1}`)
+		o.Stdoutln("[")
+	}
+	var prev string
+	for {
+		// Other ws processes may have changed the brightness.
+		if err := w.reload(); err != nil {
+			return o.Err(err)
+		}
+		lines, err := listDesktops.Run(o, d)
+		if err != nil {
+			return err
+		}
+		s, err := w.forDisplay(display).formatDesktops(format, lines)
+		if err != nil {
+			return o.Err(err)
+		}
+		if s != prev {
+			if format == i3barFormat {
+				o.Stdoutln(s + ",")
+			} else {
+				o.Stdoutln(s)
+			}
+			prev = s
+		}
+		time.Sleep(statusPollInterval)
+	}
+}
+
+func (w *Workspace) formatDesktops(format string, lines []string) (string, error) {
+	desktops, err := parseDesktops(lines)
+	if err != nil {
+		return "", err
+	}
+	return formatStatus(format, w.statuses(desktops))
+}
@@ -0,0 +1,37 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatStatus(t *testing.T) {
+	statuses := []*workspaceStatus{
+		{Index: 0, Name: "main", Current: true, Brightness: 100},
+		{Index: 1, Name: "web", Brightness: 40},
+	}
+	for _, test := range []struct {
+		format string
+		want   string
+	}{
+		{
+			format: textFormat,
+			want:   "[0:main(100%)] 1:web(40%)",
+		},
+		{
+			format: i3barFormat,
+			want:   `[{"name":"workspace","instance":"0","full_text":"[0:main 100%]"},{"name":"workspace","instance":"1","full_text":"1:web 40%"}]`,
+		},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			got, err := formatStatus(test.format, statuses)
+			if err != nil {
+				t.Fatalf("formatStatus(%q) returned error: %v", test.format, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("formatStatus(%q) returned unexpected output (-want, +got):\n%s", test.format, diff)
+			}
+		})
+	}
+}
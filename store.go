@@ -0,0 +1,212 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/leep-frog/command"
+)
+
+const (
+	// stateFileEnv overrides the location of the state file.
+	stateFileEnv = "WS_STATE_FILE"
+
+	// coalesceWindow is how long after a switch that subsequent commands
+	// treat the switch's target as the current workspace. The shell runs
+	// `wmctrl -s` after ws exits, so `wmctrl -d` may still report the old
+	// workspace when keys are pressed in quick succession.
+	coalesceWindow = 1500 * time.Millisecond
+)
+
+var (
+	// Stubbed out for tests.
+	now = time.Now
+)
+
+// store is the locked, authoritative copy of the Workspace state. sourcerer
+// also saves the Workspace, but its copy may be stale when multiple ws
+// processes run at once, so all updates re-read the state from the store
+// while holding its lock.
+type store struct {
+	path string
+}
+
+// storedState is the contents of the state file.
+type storedState struct {
+	Workspace *Workspace
+	// Pending is the most recent switch made by ws.
+	Pending *pendingSwitch `json:",omitempty"`
+}
+
+type pendingSwitch struct {
+	Workspace int
+	// Display is the display that was switched (empty for the default
+	// display).
+	Display string `json:",omitempty"`
+	Time    time.Time
+}
+
+func defaultStore() *store {
+	if p := os.Getenv(stateFileEnv); p != "" {
+		return &store{p}
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return &store{filepath.Join(dir, "leep-frog-workspace", "state.json")}
+}
+
+// lock acquires an exclusive lock on the state file and returns the function
+// that releases it.
+func (s *store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %v", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock state: %v", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// load reads the state file. If the file doesn't exist, ok is false.
+func (s *store) load() (ss *storedState, ok bool, err error) {
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read state: %v", err)
+	}
+	ss = &storedState{Workspace: &Workspace{}}
+	if err := json.Unmarshal(b, ss); err != nil {
+		return nil, false, fmt.Errorf("failed to parse state: %v", err)
+	}
+	return ss, true, nil
+}
+
+// save atomically replaces the state file.
+func (s *store) save(ss *storedState) error {
+	b, err := json.Marshal(ss)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save state: %v", err)
+	}
+	return nil
+}
+
+// setState replaces the persisted fields of w with those of from.
+func (w *Workspace) setState(from *Workspace) {
+	st, changed := w.store, w.changed
+	*w = *from
+	w.store, w.changed, w.switched = st, changed, nil
+}
+
+// reload replaces the workspace's state with the stored state (if any).
+func (w *Workspace) reload() error {
+	if w.store == nil {
+		return nil
+	}
+	ss, ok, err := w.store.load()
+	if err != nil || !ok {
+		return err
+	}
+	w.setState(ss.Workspace)
+	return nil
+}
+
+// reader returns a processor that runs f against the stored state. Other ws
+// processes (e.g. `ws serve`) may have changed the state since sourcerer
+// loaded it.
+func (w *Workspace) reader(f func(command.Output, *command.Data) error) command.Processor {
+	return &command.ExecutorProcessor{F: func(o command.Output, d *command.Data) error {
+		if err := w.reload(); err != nil {
+			return o.Err(err)
+		}
+		return f(o, d)
+	}}
+}
+
+// update runs f while holding the store's lock. The workspace state is
+// re-read before f runs and saved after it succeeds. If another ws process
+// recently switched workspaces on the display, the workspace it switched to
+// is passed to f since the shell may not have actually switched yet.
+func (w *Workspace) update(display string, f func(pending *int) error) error {
+	if w.store == nil {
+		return f(nil)
+	}
+	unlock, err := w.store.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ss, ok, err := w.store.load()
+	if err != nil {
+		return err
+	}
+	var pending *int
+	if ok {
+		w.setState(ss.Workspace)
+		if p := ss.Pending; p != nil && p.Display == display && now().Sub(p.Time) < coalesceWindow {
+			pending = &p.Workspace
+		}
+	} else {
+		ss = &storedState{}
+	}
+
+	if err := f(pending); err != nil {
+		return err
+	}
+	t := w.forDisplay(display)
+	if t.switched != nil {
+		ss.Pending = &pendingSwitch{*t.switched, display, now()}
+	} else if !w.changed && !t.changed {
+		return nil
+	}
+	ss.Workspace = w
+	return w.store.save(ss)
+}
+
+// transaction runs f for the CLI as part of an update.
+func (w *Workspace) transaction(display string, f workspaceFunc, o command.Output, d *command.Data) ([]string, error) {
+	var r []string
+	err := w.update(display, func(pending *int) error {
+		if pending != nil {
+			d.Set(currentWorkspaceKey, *pending)
+		}
+		var err error
+		r, err = w.apply(display, f, o, d)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,563 @@
+package workspace
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/leep-frog/command"
+	"github.com/leep-frog/workspace/workspacetest"
+)
+
+func TestStoreLocking(t *testing.T) {
+	s := &store{filepath.Join(t.TempDir(), "nested", "state.json")}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := s.lock()
+			if err != nil {
+				t.Errorf("lock() returned error: %v", err)
+				return
+			}
+			defer unlock()
+
+			ss, ok, err := s.load()
+			if err != nil {
+				t.Errorf("load() returned error: %v", err)
+				return
+			}
+			if !ok {
+				ss = &storedState{Workspace: &Workspace{}}
+			}
+			ss.Workspace.profile(0).Brightness++
+			if err := s.save(ss); err != nil {
+				t.Errorf("save() returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ss, ok, err := s.load()
+	if err != nil || !ok {
+		t.Fatalf("load() returned (%v, %v); want (true, nil)", ok, err)
+	}
+	if diff := cmp.Diff(20, ss.Workspace.brightness(0)); diff != "" {
+		t.Errorf("Concurrent updates produced unexpected brightness (-want, +got):\n%s", diff)
+	}
+}
+
+func TestStoreLoadMissing(t *testing.T) {
+	s := &store{filepath.Join(t.TempDir(), "state.json")}
+	ss, ok, err := s.load()
+	if ss != nil || ok || err != nil {
+		t.Errorf("load() returned (%v, %v, %v); want (nil, false, nil)", ss, ok, err)
+	}
+}
+
+// executeOnDesktop runs etc with the queries in etc.WantRunContents answered
+// from d. If apply is set, the executables (which ExecuteTest checks against
+// etc.WantExecuteData) are then run on d.
+func executeOnDesktop(t *testing.T, d *workspacetest.Desktop, etc *command.ExecuteTestCase, apply bool) {
+	t.Helper()
+	etc.RunResponses = d.FakeRuns(etc.WantRunContents...)
+	command.ExecuteTest(t, etc)
+	if apply && etc.WantExecuteData != nil {
+		if err := d.Apply(etc.WantExecuteData.Executable); err != nil {
+			t.Fatalf("Apply() returned error: %v", err)
+		}
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	start := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	defer func() { now = oldNow }()
+
+	d := workspacetest.New(4, "DP-1")
+	d.Current = 1
+	s := &store{filepath.Join(t.TempDir(), "state.json")}
+	// Stale state loaded by sourcerer should be ignored in favor of the store.
+	stale := &Workspace{Prev: intPtr(1), store: s}
+	if err := s.save(&storedState{Workspace: &Workspace{
+		Prev: intPtr(3),
+		Profiles: map[int]*Profile{
+			3: {Brightness: 50},
+		},
+	}}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	for _, step := range []struct {
+		name string
+		// elapsed is the time since start.
+		elapsed time.Duration
+		// before changes the desktop outside of ws.
+		before func()
+		etc    func() *command.ExecuteTestCase
+		// lag is whether the window manager hasn't switched workspaces by the
+		// next step.
+		lag            bool
+		want           *Workspace
+		wantCurrent    int
+		wantBrightness float64
+	}{
+		{
+			name: "first right move",
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"right"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"wmctrl -s 2",
+							"xrandr --output DP-1 --brightness 1.00",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops": d.Query(dq),
+						},
+					},
+				}
+			},
+			lag: true,
+			want: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 50},
+				},
+			},
+			wantCurrent:    1,
+			wantBrightness: 1,
+		},
+		{
+			name:    "second right move uses pending workspace",
+			elapsed: 100 * time.Millisecond,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"right"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"wmctrl -s 3",
+							"xrandr --output DP-1 --brightness 0.50",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops":         d.Query(dq),
+							"currentWorkspace": 2,
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 50},
+				},
+			},
+			wantCurrent:    3,
+			wantBrightness: 0.5,
+		},
+		{
+			name:    "brightness change applies to pending workspace",
+			elapsed: 200 * time.Millisecond,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"brightness", "up"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"xrandr --output DP-1 --brightness 0.60",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops":         d.Query(dq),
+							"currentWorkspace": 3,
+							"mcs":              []string{"DP-1"},
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 60},
+				},
+				Journal: &Journal{
+					Undo: []*Settings{{
+						Profiles: map[int]*Profile{
+							3: {Brightness: 50},
+						},
+					}},
+				},
+			},
+			wantCurrent:    3,
+			wantBrightness: 0.6,
+		},
+		{
+			name:    "pending workspace expires",
+			elapsed: 200*time.Millisecond + coalesceWindow,
+			before:  func() { d.Current = 0 },
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"left"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"wmctrl -s 3",
+							"xrandr --output DP-1 --brightness 0.60",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops": d.Query(dq),
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				Prev: intPtr(0),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 60},
+				},
+				Journal: &Journal{
+					Undo: []*Settings{{
+						Profiles: map[int]*Profile{
+							3: {Brightness: 50},
+						},
+					}},
+				},
+			},
+			wantCurrent:    3,
+			wantBrightness: 0.6,
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			now = func() time.Time { return start.Add(step.elapsed) }
+			if step.before != nil {
+				step.before()
+			}
+			// Each step uses a separate Workspace, like separate ws processes.
+			w := &Workspace{}
+			w.setState(stale)
+			w.store = s
+			etc := step.etc()
+			etc.Node = w.Node()
+			executeOnDesktop(t, d, etc, !step.lag)
+
+			ss, ok, err := s.load()
+			if err != nil || !ok {
+				t.Fatalf("load() returned (%v, %v); want (true, nil)", ok, err)
+			}
+			if diff := cmp.Diff(step.want, ss.Workspace, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+				t.Errorf("Transaction saved unexpected state (-want, +got):\n%s", diff)
+			}
+			if d.Current != step.wantCurrent {
+				t.Errorf("desktop is on workspace %d; want %d", d.Current, step.wantCurrent)
+			}
+			if b, _ := d.Brightness("DP-1"); b != step.wantBrightness {
+				t.Errorf("DP-1 has brightness %v; want %v", b, step.wantBrightness)
+			}
+		})
+	}
+}
+
+func TestPerMonitorTransactions(t *testing.T) {
+	start := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	defer func() { now = oldNow }()
+
+	// i3 names each desktop after its workspace, so desktop 2 is workspace
+	// number 5.
+	d := &workspacetest.Desktop{
+		Workspaces: []string{"1", "2", "5"},
+		Current:    1,
+		Outputs:    []*workspacetest.Output{{Name: "eDP-1", Brightness: 1}},
+	}
+	s := &store{filepath.Join(t.TempDir(), "state.json")}
+	if err := s.save(&storedState{Workspace: &Workspace{
+		PerMonitor: true,
+		Profiles: map[int]*Profile{
+			2: {Brightness: 50},
+		},
+	}}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+	journal := func(brightness ...int) *Journal {
+		j := &Journal{}
+		for _, b := range brightness {
+			j.Undo = append(j.Undo, &Settings{
+				Profiles: map[int]*Profile{
+					2: {Brightness: b},
+				},
+				PerMonitor: true,
+			})
+		}
+		return j
+	}
+
+	for _, step := range []struct {
+		name string
+		// elapsed is the time since start.
+		elapsed        time.Duration
+		etc            func() *command.ExecuteTestCase
+		want           *Workspace
+		wantCurrent    int
+		wantBrightness float64
+	}{
+		{
+			name: "right move uses the desktop's brightness",
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"right"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"i3-msg workspace number 5",
+							"xrandr --output eDP-1 --brightness 0.50",
+						},
+					},
+					WantRunContents: [][]string{dCmd, i3Cmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops": d.Query(dq),
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 50},
+				},
+			},
+			wantCurrent:    2,
+			wantBrightness: 0.5,
+		},
+		{
+			name:    "brightness change applies to the pending desktop",
+			elapsed: 100 * time.Millisecond,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"brightness", "up"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"xrandr --output eDP-1 --brightness 0.60",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops":         d.Query(dq),
+							"currentWorkspace": 2,
+							"mcs":              []string{"eDP-1"},
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 60},
+				},
+				Journal: journal(50),
+			},
+			wantCurrent:    2,
+			wantBrightness: 0.6,
+		},
+		{
+			name:    "brightness change applies to the same desktop after the switch",
+			elapsed: 200*time.Millisecond + coalesceWindow,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"brightness", "up"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"xrandr --output eDP-1 --brightness 0.70",
+						},
+					},
+					WantRunContents: [][]string{dCmd, lmCmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops": d.Query(dq),
+							"mcs":      []string{"eDP-1"},
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 70},
+				},
+				Journal: journal(50, 60),
+			},
+			wantCurrent:    2,
+			wantBrightness: 0.7,
+		},
+		{
+			name:    "back move uses the default brightness",
+			elapsed: 300*time.Millisecond + coalesceWindow,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"back"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"i3-msg workspace number 2",
+							"xrandr --output eDP-1 --brightness 1.00",
+						},
+					},
+					WantRunContents: [][]string{dCmd, i3Cmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops": d.Query(dq),
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 5,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 70},
+				},
+				Journal: journal(50, 60),
+			},
+			wantCurrent:    1,
+			wantBrightness: 1,
+		},
+		{
+			name:    "right move applies the brightness set with brightness up",
+			elapsed: 400*time.Millisecond + coalesceWindow,
+			etc: func() *command.ExecuteTestCase {
+				return &command.ExecuteTestCase{
+					Args: []string{"right"},
+					WantExecuteData: &command.ExecuteData{
+						Executable: []string{
+							"i3-msg workspace number 5",
+							"xrandr --output eDP-1 --brightness 0.70",
+						},
+					},
+					WantRunContents: [][]string{dCmd, i3Cmd},
+					WantData: &command.Data{
+						Values: map[string]interface{}{
+							"desktops":         d.Query(dq),
+							"currentWorkspace": 1,
+						},
+					},
+				}
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 70},
+				},
+				Journal: journal(50, 60),
+			},
+			wantCurrent:    2,
+			wantBrightness: 0.7,
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			now = func() time.Time { return start.Add(step.elapsed) }
+			w := &Workspace{store: s}
+			etc := step.etc()
+			etc.Node = w.Node()
+			executeOnDesktop(t, d, etc, true)
+
+			ss, ok, err := s.load()
+			if err != nil || !ok {
+				t.Fatalf("load() returned (%v, %v); want (true, nil)", ok, err)
+			}
+			if diff := cmp.Diff(step.want, ss.Workspace, cmpopts.IgnoreUnexported(Workspace{})); diff != "" {
+				t.Errorf("Transaction saved unexpected state (-want, +got):\n%s", diff)
+			}
+			if d.Current != step.wantCurrent {
+				t.Errorf("desktop is on workspace %d; want %d", d.Current, step.wantCurrent)
+			}
+			if b, _ := d.Brightness("eDP-1"); b != step.wantBrightness {
+				t.Errorf("eDP-1 has brightness %v; want %v", b, step.wantBrightness)
+			}
+		})
+	}
+}
+
+func TestReadsUseStoredState(t *testing.T) {
+	s := &store{filepath.Join(t.TempDir(), "state.json")}
+	// Stale state loaded by sourcerer should be ignored in favor of the store.
+	stale := &Workspace{
+		Profiles: map[int]*Profile{
+			2: {Brightness: 90},
+		},
+		store: s,
+	}
+	if err := s.save(&storedState{Workspace: &Workspace{
+		Profiles: map[int]*Profile{
+			2: {Brightness: 40},
+		},
+		Presets: map[string]*Preset{
+			"night": {Brightness: 30},
+		},
+		Rules: []*BrightnessRule{
+			{Pattern: "mpv", Brightness: 100},
+		},
+	}}); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		etc  *command.ExecuteTestCase
+	}{
+		{
+			name: "lists stored brightness",
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "list"},
+				WantStdout: " 2: 40\n",
+			},
+		},
+		{
+			name: "lists stored presets",
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "preset", "list"},
+				WantStdout: "night: 30\n",
+			},
+		},
+		{
+			name: "lists stored rules",
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "rule", "list"},
+				WantStdout: " 0: class \"mpv\" -> 100\n",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := &Workspace{}
+			*w = *stale
+			test.etc.Node = w.Node()
+			command.ExecuteTest(t, test.etc)
+		})
+	}
+}
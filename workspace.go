@@ -12,18 +12,13 @@ const (
 	workspaceArg  = "WORKSPACE"
 	monitorArg    = "MONITOR_CODE"
 	brightnessArg = "BRIGHTNESS"
+
+	defaultBrightness = 100
+	minBrightness     = 5
+	maxBrightness     = 250
 )
 
 var (
-	nArg = &command.BashCommand[int]{
-		ArgName:  "numWorkspaces",
-		Contents: []string{"wmctrl -d | wc | awk '{ print $1 }'"},
-	}
-	cwArg = &command.BashCommand[int]{
-		ArgName:  "currentWorkspace",
-		Contents: []string{`wmctrl -d | awk '{ if ($2 == "'*'") print $1 }'`},
-	}
-
 	listMcs = &command.BashCommand[[]string]{
 		ArgName:  "mcs",
 		Contents: []string{`xrandr --query | grep "\bconnected" | awk '{print $1}' | grep -v ^\s*$`},
@@ -31,13 +26,74 @@ var (
 )
 
 func CLI() *Workspace {
-	return &Workspace{}
+	return &Workspace{
+		store: defaultStore(),
+	}
 }
 
 type Workspace struct {
-	Prev       int
-	Brightness map[int]int
-	changed    bool
+	// Prev is the previous workspace. If nil, no move has been recorded yet.
+	Prev *int
+	// Profiles are the settings for each workspace.
+	Profiles map[int]*Profile
+	// SkipEmpty is whether left and right skip workspaces with no windows.
+	SkipEmpty bool
+	// Scratch is the scratch workspace. If nil, the last workspace is used.
+	Scratch *int
+	// ScratchReturn is the workspace to return to when leaving the scratch
+	// workspace. This is tracked separately from Prev so that a quick look
+	// at the scratch workspace doesn't change where `ws back` goes.
+	ScratchReturn int
+	// PerMonitor is whether workspace commands operate on the workspaces of
+	// the focused monitor rather than on the global list of desktops.
+	PerMonitor bool `json:",omitempty"`
+	// MonitorPrev is the previous workspace number for each monitor when
+	// PerMonitor is set.
+	MonitorPrev map[string]int `json:",omitempty"`
+	// LockedMonitors are the monitors whose brightness is never changed.
+	LockedMonitors []string `json:",omitempty"`
+	// Presets are the named brightness presets.
+	Presets map[string]*Preset `json:",omitempty"`
+	// Rules override the brightness while a matching window is focused.
+	Rules []*BrightnessRule `json:",omitempty"`
+	// RuleBrightness is the brightness of the rule that `ws brightness rule
+	// watch` is currently applying, if any.
+	RuleBrightness *int `json:",omitempty"`
+	// PresentationWorkspace is the workspace that `ws present` switches to.
+	// If nil, `ws present` stays on the current workspace.
+	PresentationWorkspace *int `json:",omitempty"`
+	// Presentation is the state to restore when the current presentation
+	// stops, or nil if there is no presentation in progress.
+	Presentation *Presentation `json:",omitempty"`
+	// Journal is the history of settings changes for `ws undo` and
+	// `ws redo`.
+	Journal *Journal `json:",omitempty"`
+	// Displays is the state for each display other than the default one.
+	Displays map[string]*Workspace `json:",omitempty"`
+
+	changed bool
+	// store is where the state is saved and locked. If nil, no locking is done.
+	store *store
+	// switched is the workspace that was switched to by the current command.
+	switched *int
+}
+
+// Profile is the settings for a single workspace.
+type Profile struct {
+	// Brightness is the monitor brightness percentage. If zero, the default
+	// brightness is used.
+	Brightness int `json:",omitempty"`
+}
+
+// profile returns the profile for workspace n, creating it if necessary.
+func (w *Workspace) profile(n int) *Profile {
+	if w.Profiles == nil {
+		w.Profiles = map[int]*Profile{}
+	}
+	if w.Profiles[n] == nil {
+		w.Profiles[n] = &Profile{}
+	}
+	return w.Profiles[n]
 }
 
 func (*Workspace) Name() string {
@@ -52,154 +108,432 @@ func (*Workspace) Setup() []string {
 	return nil
 }
 
-func (w *Workspace) moveRelative(offset int, output command.Output, data *command.Data) ([]string, error) {
-	n := nArg.Get(data)
-	c := cwArg.Get(data)
-	if n <= 0 {
-		return nil, output.Stderrln("couldn't get number of workspaces")
+func (w *Workspace) moveRelative(offset int, e env) ([]string, error) {
+	if w.PerMonitor {
+		return w.moveRelativeOnOutput(offset, e)
+	}
+	n, err := e.numWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
 	}
-	var newWS int
-	for newWS = c + offset; newWS < 0; newWS += n {
+	if w.SkipEmpty {
+		windows, err := windowCounts(e)
+		if err != nil {
+			return nil, err
+		}
+		if ws, ok := nextOccupied(c, n, offset, windows); ok {
+			return w.moveTo(ws, e)
+		}
 	}
-	newWS = newWS % n
-	return w.moveTo(newWS, output, data)
+	return w.moveTo(wrapWorkspace(c+offset, n), e)
 }
 
-func (w *Workspace) moveTo(n int, output command.Output, data *command.Data) ([]string, error) {
-	c := cwArg.Get(data)
+func (w *Workspace) moveTo(n int, e env) ([]string, error) {
+	c, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
 	// If we're already in the workspace, then just return.
 	if n == c {
 		return nil, nil
 	}
-	w.Prev = c
+	w.Prev = &c
 	w.changed = true
+	return w.switchTo(n, e), nil
+}
+
+// switchTo returns the executables for switching to the provided workspace
+// without updating any state.
+func (w *Workspace) switchTo(n int, e env) []string {
+	w.switched = &n
 	r := []string{
 		fmt.Sprintf("wmctrl -s %d", n),
 	}
-	b, ok := w.Brightness[n]
-	if !ok {
-		b = 100
-	}
-	mcs, err := listMcs.Run(output, data)
+	b := w.brightness(n)
+	mcs, err := e.monitors()
 	if err != nil {
-		output.Annotate(err, "Failed to get monitor codes")
+		e.warn(err, "Failed to get monitor codes")
 	} else {
-		r = append(r, setBrightness(mcs, b)...)
+		r = append(r, w.setBrightness(mcs, b)...)
+	}
+	return r
+}
+
+// brightness returns the configured brightness for the provided workspace.
+func (w *Workspace) brightness(n int) int {
+	if p := w.Profiles[n]; p != nil && p.Brightness != 0 {
+		return p.Brightness
 	}
-	return r, nil
+	return defaultBrightness
 }
 
-func setBrightness(mcs []string, brightness int) []string {
+// configuredBrightness returns the workspaces that have a brightness set, in
+// ascending order.
+func (w *Workspace) configuredBrightness() []int {
+	var r []int
+	for k, p := range w.Profiles {
+		if p != nil && p.Brightness != 0 {
+			r = append(r, k)
+		}
+	}
+	sort.Ints(r)
+	return r
+}
+
+// setBrightness returns the executables for setting the brightness of all
+// of the provided monitors that aren't locked.
+func (w *Workspace) setBrightness(mcs []string, brightness int) []string {
 	var r []string
 	for _, mc := range mcs {
+		if w.locked(mc) {
+			continue
+		}
 		r = append(r, fmt.Sprintf("xrandr --output %s --brightness %0.2f", strings.TrimSpace(mc), float64(brightness)/100.0))
 	}
 	return r
 }
 
+// adjustBrightness changes the brightness of the current workspace by offset,
+// stopping at the supported range.
+func (w *Workspace) adjustBrightness(offset int, e env) ([]string, error) {
+	cw, err := e.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	mcs, err := e.monitors()
+	if err != nil {
+		return nil, err
+	}
+	b := w.brightness(cw) + offset
+	if b < minBrightness {
+		b = minBrightness
+	} else if b > maxBrightness {
+		b = maxBrightness
+	}
+	w.profile(cw).Brightness = b
+	w.changed = true
+	return w.setBrightness(mcs, b), nil
+}
+
+func (w *Workspace) setBrightnessFor(ws, pct int) error {
+	if pct < minBrightness || pct > maxBrightness {
+		return &BrightnessRangeError{pct}
+	}
+	w.profile(ws).Brightness = pct
+	w.changed = true
+	return nil
+}
+
 func (w *Workspace) nthWorkspace(output command.Output, data *command.Data) ([]string, error) {
-	return w.moveTo(data.Int(workspaceArg), output, data)
+	e := newCLIEnv(output, data)
+	if w.PerMonitor {
+		return w.moveOnOutput(e, func(oe *outputEnv) (int, error) {
+			return resolveWorkspace(data, oe)
+		})
+	}
+	n, err := resolveWorkspace(data, e)
+	if err != nil {
+		return nil, err
+	}
+	return w.moveTo(n, e)
 }
 
 func (w *Workspace) moveBack(output command.Output, data *command.Data) ([]string, error) {
-	return w.moveTo(w.Prev, output, data)
+	e := newCLIEnv(output, data)
+	if w.PerMonitor {
+		return w.moveBackOnOutput(e)
+	}
+	return w.moveTo(w.prev(), e)
+}
+
+// prev returns the previous workspace, or the first workspace if no move has
+// been recorded yet.
+func (w *Workspace) prev() int {
+	if w.Prev == nil {
+		return 0
+	}
+	return *w.Prev
 }
 
 func (w *Workspace) moveLeft(output command.Output, data *command.Data) ([]string, error) {
-	return w.moveRelative(-1, output, data)
+	return w.moveRelative(-1, newCLIEnv(output, data))
 }
 
 func (w *Workspace) moveRight(output command.Output, data *command.Data) ([]string, error) {
-	return w.moveRelative(1, output, data)
+	return w.moveRelative(1, newCLIEnv(output, data))
 }
 
-func (w *Workspace) offsetBrightness(offset int) func(o command.Output, d *command.Data) ([]string, error) {
-	return func(o command.Output, d *command.Data) ([]string, error) {
-		cw := cwArg.Get(d)
-		b := 100
-		if eb, ok := w.Brightness[cw]; ok {
-			b = eb
-		}
-		b += offset
-		if w.Brightness == nil {
-			w.Brightness = map[int]int{}
-		}
-		w.Brightness[cw] = b
-		w.changed = true
-		return setBrightness(listMcs.Get(d), b), nil
+func offsetBrightness(offset int) workspaceFunc {
+	return func(w *Workspace, o command.Output, d *command.Data) ([]string, error) {
+		return w.adjustBrightness(offset, newCLIEnv(o, d))
+	}
+}
+
+func (w *Workspace) setWorkspaceBrightness(o command.Output, d *command.Data) error {
+	n, err := resolveWorkspace(d, newCLIEnv(o, d))
+	if err != nil {
+		return err
 	}
+	return w.setBrightnessFor(n, d.Int(brightnessArg))
+}
+
+func (w *Workspace) listBrightness(o command.Output, d *command.Data) error {
+	t := w.forDisplay(displayFlag.Get(d))
+	for _, k := range t.configuredBrightness() {
+		o.Stdoutf("%2d: %d\n", k, t.Profiles[k].Brightness)
+	}
+	return nil
 }
 
 func (w *Workspace) Node() command.Node {
-	wn := command.Arg[int](workspaceArg, "Workspace number", command.NonNegative[int]())
+	wn := command.Arg[string](workspaceArg, workspaceArgDesc, w.workspaceCompleter())
 	return &command.BranchNode{
 		Branches: map[string]command.Node{
-			"left":  command.SerialNodes(command.Description("Move one workspace left"), nArg, cwArg, command.ExecutableProcessor(w.moveLeft)),
-			"right": command.SerialNodes(command.Description("Move one workspace right"), nArg, cwArg, command.ExecutableProcessor(w.moveRight)),
-			"back":  command.SerialNodes(command.Description("Move to the previous"), cwArg, command.ExecutableProcessor(w.moveBack)),
+			"left":          command.SerialNodes(command.Description("Move one workspace left"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, w.executable((*Workspace).moveLeft)),
+			"right":         command.SerialNodes(command.Description("Move one workspace right"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, w.executable((*Workspace).moveRight)),
+			"back":          command.SerialNodes(command.Description("Move to the previous"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, w.executable((*Workspace).moveBack)),
+			"next-occupied": command.SerialNodes(command.Description("Move to the next workspace on the right that has windows"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, listWindows, w.executable((*Workspace).moveNextOccupied)),
+			"prev-occupied": command.SerialNodes(command.Description("Move to the next workspace on the left that has windows"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, listWindows, w.executable((*Workspace).movePrevOccupied)),
+			"empty":         command.SerialNodes(command.Description("Move to the first workspace with no windows"), command.FlagNode(dryRunFlag, displayFlag), useDisplay, listDesktops, listWindows, w.executable((*Workspace).moveEmpty)),
+			"skip-empty": command.SerialNodes(
+				command.Description("Set whether left and right skip workspaces with no windows"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				command.Arg[bool](skipEmptyArg, "Whether to skip empty workspaces", command.SimpleCompleter[bool]("true", "false")),
+				w.executor((*Workspace).setSkipEmpty),
+			),
+			"per-monitor": command.SerialNodes(
+				command.Description("Set whether workspace commands operate on the focused monitor's workspaces (requires i3)"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				command.Arg[bool](perMonitorArg, "Whether each monitor has its own workspaces", command.SimpleCompleter[bool]("true", "false")),
+				w.executor((*Workspace).setPerMonitor),
+			),
+			"focus-monitor": command.SerialNodes(
+				command.Description("Move focus to the monitor on the left or right (requires i3)"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				command.Arg[string](directionArg, "Direction of the monitor to focus", command.InList("left", "right")),
+				w.executable((*Workspace).focusMonitor),
+			),
+			"swap": command.SerialNodes(
+				command.Description("Swap the windows and settings of two workspaces"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				command.Arg[string](swapAArg, workspaceArgDesc),
+				command.Arg[string](swapBArg, workspaceArgDesc),
+				listDesktops,
+				listWindows,
+				w.executable((*Workspace).swap),
+			),
+			"move-to": command.SerialNodes(
+				command.Description("Move a workspace's windows and settings to a new position, shifting the workspaces in between"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				command.Arg[string](moveFromArg, workspaceArgDesc),
+				command.Arg[string](moveToArg, workspaceArgDesc),
+				listDesktops,
+				listWindows,
+				w.executable((*Workspace).moveWorkspace),
+			),
+			"present": &command.BranchNode{
+				Branches: map[string]command.Node{
+					"stop": command.SerialNodes(
+						command.Description("Restore the settings, outputs and workspace from before the presentation"),
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+						listDesktops,
+						w.executable((*Workspace).stopPresenting),
+					),
+					"workspace": command.SerialNodes(
+						command.Description("Set the workspace to present from"),
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+						wn,
+						listDesktops,
+						w.checkWorkspace(),
+						w.executor((*Workspace).setPresentationWorkspace),
+					),
+				},
+				Default: command.SerialNodes(
+					command.Description("Mirror (or extend) onto a projector and present from the presentation workspace at full brightness"),
+					command.FlagNode(dryRunFlag, displayFlag, presentExtendFlag), useDisplay,
+					command.OptionalArg[string](outputArg, "Projector output (defaults to the only connected non-primary output)", outputCompleter(false)),
+					listDesktops,
+					xrandrQuery,
+					w.executable((*Workspace).present),
+				),
+			},
+			"scratch": &command.BranchNode{
+				Branches: map[string]command.Node{
+					"set": command.SerialNodes(
+						command.Description("Set the scratch workspace and optionally its brightness"),
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+						wn,
+						listDesktops,
+						w.checkWorkspace(),
+						command.OptionalArg[int](brightnessArg, "Scratch workspace brightness", command.GTE(minBrightness), command.LTE(maxBrightness)),
+						w.executor((*Workspace).setScratch),
+					),
+				},
+				Default: command.SerialNodes(
+					command.Description("Toggle between the scratch workspace and the workspace it was opened from"),
+					command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+					listDesktops,
+					w.executable((*Workspace).toggleScratch),
+				),
+			},
+			"undo": command.SerialNodes(
+				command.Description("Undo the last settings change (e.g. brightness)"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				listDesktops,
+				w.executable((*Workspace).undo),
+			),
+			"redo": command.SerialNodes(
+				command.Description("Redo the last undone settings change"),
+				command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+				listDesktops,
+				w.executable((*Workspace).redo),
+			),
+			"serve": command.SerialNodes(
+				command.Description("Listen for JSON requests on a Unix socket"),
+				command.FlagNode(socketFlag, displayFlag),
+				&command.ExecutorProcessor{F: w.serve},
+			),
+			"dbus": command.SerialNodes(
+				command.Description("Publish the org.leepfrog.Workspace D-Bus service on the session bus"),
+				command.FlagNode(displayFlag),
+				&command.ExecutorProcessor{F: w.serveDBus},
+			),
+			"idle": command.SerialNodes(
+				command.Description("Dim the monitors after a period of no input and restore the workspace's brightness on input"),
+				command.FlagNode(idleAfterFlag, idleDimFlag, displayFlag),
+				&command.ExecutorProcessor{F: w.idle},
+			),
+			"doctor": command.SerialNodes(
+				command.Description("Check that the environment supports ws"),
+				command.FlagNode(displayFlag),
+				useDisplay,
+				&command.ExecutorProcessor{F: w.doctor},
+			),
+			"list": command.SerialNodes(
+				command.Description("List all workspaces with their windows and brightness"),
+				command.FlagNode(listFormatFlag, displayFlag), useDisplay,
+				listDesktops,
+				listWindows,
+				w.reader(w.list),
+			),
+			"status": command.SerialNodes(
+				command.Description("Print the workspaces and their brightness for status bars"),
+				command.FlagNode(statusFormatFlag, statusFollowFlag, displayFlag), useDisplay,
+				listDesktops,
+				w.reader(w.status),
+			),
 			"monitors": &command.BranchNode{
 				Branches: map[string]command.Node{
 					"list": command.SerialNodes(
 						command.Description("List monitor codes"),
+						command.FlagNode(displayFlag),
+						useDisplay,
 						listMcs,
-						&command.ExecutorProcessor{F: func(o command.Output, d *command.Data) error {
-							codes := d.StringList("mcs")
-							sort.Strings(codes)
-							for _, c := range codes {
-								o.Stdoutln(c)
-							}
-							return nil
-						}},
+						w.reader(w.listMonitors),
+					),
+					"lock": command.SerialNodes(
+						command.Description("Prevent ws from changing a monitor's brightness"),
+						command.FlagNode(dryRunFlag, displayFlag),
+						command.Arg[string](monitorArg, "Monitor code (from `ws monitors list`)"),
+						w.executor((*Workspace).lockMonitor),
+					),
+					"unlock": command.SerialNodes(
+						command.Description("Allow ws to change a locked monitor's brightness"),
+						command.FlagNode(dryRunFlag, displayFlag),
+						command.Arg[string](monitorArg, "Monitor code (from `ws monitors list`)"),
+						w.executor((*Workspace).unlockMonitor),
 					),
 				},
 			},
 			"brightness": &command.BranchNode{
 				Branches: map[string]command.Node{
 					"up": command.SerialNodes(
-						cwArg,
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+						listDesktops,
 						listMcs,
-						command.ExecutableProcessor(w.offsetBrightness(10)),
+						w.executable(journaled(offsetBrightness(10))),
 					),
 					"down": command.SerialNodes(
-						cwArg,
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+						listDesktops,
 						listMcs,
-						command.ExecutableProcessor(w.offsetBrightness(-10)),
+						w.executable(journaled(offsetBrightness(-10))),
 					),
 					"set": command.SerialNodes(
 						command.Description("Set the brightness for a workspace"),
+						command.FlagNode(dryRunFlag, displayFlag), useDisplay,
 						wn,
-						command.Arg[int](brightnessArg, "Monitor brightness", command.GTE(5), command.LTE(250)),
-						&command.ExecutorProcessor{F: func(o command.Output, d *command.Data) error {
-							if w.Brightness == nil {
-								w.Brightness = map[int]int{}
-							}
-							w.Brightness[d.Int(workspaceArg)] = d.Int(brightnessArg)
-							w.changed = true
-							return nil
-						}},
+						command.Arg[int](brightnessArg, "Monitor brightness", command.GTE(minBrightness), command.LTE(maxBrightness)),
+						w.executor((*Workspace).setWorkspaceBrightness),
+					),
+					"preset": &command.BranchNode{
+						Branches: map[string]command.Node{
+							"save": command.SerialNodes(
+								command.Description("Save a brightness preset, or the brightness of every workspace if no brightness is provided"),
+								command.FlagNode(dryRunFlag, displayFlag),
+								command.Arg[string](presetArg, "Preset name"),
+								command.OptionalArg[int](brightnessArg, "Monitor brightness", command.GTE(minBrightness), command.LTE(maxBrightness)),
+								w.executor((*Workspace).savePreset),
+							),
+							"apply": command.SerialNodes(
+								command.Description("Apply a brightness preset to a workspace or all workspaces"),
+								command.FlagNode(dryRunFlag, displayFlag), useDisplay,
+								command.Arg[string](presetArg, "Preset name"),
+								command.OptionalArg[string](presetTargetArg, workspaceArgDesc+", or all", command.SimpleCompleter[string]("all")),
+								w.executable(journaled((*Workspace).applyNamedPreset)),
+							),
+							"list": command.SerialNodes(
+								command.Description("List brightness presets"),
+								command.FlagNode(displayFlag),
+								w.reader(w.listPresets),
+							),
+						},
+					},
+					"rule": &command.BranchNode{
+						Branches: map[string]command.Node{
+							"add": command.SerialNodes(
+								command.Description("Override the brightness while a window with a matching class (or title) is focused"),
+								command.FlagNode(dryRunFlag, ruleTitleFlag),
+								command.Arg[string](patternArg, "Regular expression for the window class (or title)"),
+								command.Arg[int](brightnessArg, "Monitor brightness", command.GTE(minBrightness), command.LTE(maxBrightness)),
+								w.executor((*Workspace).addRule),
+							),
+							"list": command.SerialNodes(
+								command.Description("List brightness rules"),
+								w.reader(w.listRules),
+							),
+							"remove": command.SerialNodes(
+								command.Description("Remove a brightness rule"),
+								command.FlagNode(dryRunFlag),
+								command.Arg[int](ruleIndexArg, "Index of the rule (from `ws brightness rule list`)", command.NonNegative[int]()),
+								w.executor((*Workspace).removeRule),
+							),
+						},
+					},
+					"watch": command.SerialNodes(
+						command.Description("Apply brightness rules when the focused window changes"),
+						command.FlagNode(displayFlag),
+						&command.ExecutorProcessor{F: w.watchRules},
 					),
 					"list": command.SerialNodes(
 						command.Description("List brightnesses for each workspace"),
-						&command.ExecutorProcessor{F: func(o command.Output, d *command.Data) error {
-							var keys []int
-							for k := range w.Brightness {
-								keys = append(keys, k)
-							}
-							sort.Ints(keys)
-							for _, k := range keys {
-								o.Stdoutf("%2d: %d\n", k, w.Brightness[k])
-							}
-							return nil
-						}},
+						command.FlagNode(displayFlag),
+						w.reader(w.listBrightness),
 					),
 				},
 			},
 		},
 		Default: command.SerialNodes(
 			command.Description("Move to a specific workspace"),
+			command.FlagNode(dryRunFlag, displayFlag), useDisplay,
 			wn,
-			cwArg,
-			command.ExecutableProcessor(w.nthWorkspace),
+			listDesktops,
+			w.checkWorkspace(),
+			w.executable((*Workspace).nthWorkspace),
 		),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/leep-frog/command"
 )
@@ -14,9 +15,10 @@ type runIntResponse struct {
 	err error
 }
 
-func nRun(n int) *command.FakeRun {
+// desktopsRun returns a FakeRun for `wmctrl -d` with n desktops.
+func desktopsRun(n, current int) *command.FakeRun {
 	return &command.FakeRun{
-		Stdout: []string{fmt.Sprintf("%d", n)},
+		Stdout: numberedDesktops(n, current),
 	}
 }
 
@@ -32,20 +34,86 @@ func mcRun(mcs ...string) *command.FakeRun {
 	}
 }
 
+// The scripts that runnerEnv runs for each query.
+var (
+	dq  = query(listDesktops)
+	wq  = query(listWindows)
+	lm  = query(listMcs)
+	i3q = query(listOutputWorkspaces)
+)
+
+// The contents that the CLI runs for each query.
+var (
+	dCmd  = runContents(listDesktops)
+	wCmd  = runContents(listWindows)
+	lmCmd = runContents(listMcs)
+	i3Cmd = runContents(listOutputWorkspaces)
+	xqCmd = runContents(xrandrQuery)
+)
+
+// query returns the script that runnerEnv runs for bc.
+func query[T any](bc *command.BashCommand[T]) string {
+	return strings.Join(bc.Contents, "\n")
+}
+
+// runContents returns the contents that the CLI runs for bc.
+func runContents[T any](bc *command.BashCommand[T]) []string {
+	return append([]string{"set -e", "set -o pipefail"}, bc.Contents...)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func desktopLines(current int, names ...string) []string {
+	var r []string
+	for i, name := range names {
+		mark := "-"
+		if i == current {
+			mark = "*"
+		}
+		r = append(r, fmt.Sprintf("%d  %s DG: 3840x1080  VP: 0,0  WA: 0,0 3840x1052  %s", i, mark, name))
+	}
+	return r
+}
+
+// numberedDesktops returns the `wmctrl -d` output for n desktops.
+func numberedDesktops(n, current int) []string {
+	var names []string
+	for i := 0; i < n; i++ {
+		names = append(names, fmt.Sprintf("Workspace %d", i))
+	}
+	return desktopLines(current, names...)
+}
+
+func desktopRun(current int, names ...string) *command.FakeRun {
+	return &command.FakeRun{
+		Stdout: desktopLines(current, names...),
+	}
+}
+
 func TestWorkspace(t *testing.T) {
-	numW := []string{"set -e", "set -o pipefail", fmt.Sprintf("wmctrl -d | wc | awk '{ print $1 }'")}
-	cw := []string{"set -e", "set -o pipefail", fmt.Sprintf(`wmctrl -d | awk '{ if ($2 == "'*'") print $1 }'`)}
-	lmCmd := []string{
-		"set -e",
-		"set -o pipefail",
-		`xrandr --query | grep "\bconnected" | awk '{print $1}' | grep -v ^\s*$`,
+	xqRun := &command.FakeRun{Stdout: xrandrLines}
+	presentLayout := []*OutputLayout{
+		{Name: "eDP-1", Mode: "1920x1080", Primary: true},
+		{Name: "HDMI-1"},
+		{Name: "DP-2", Mode: "1920x1080", X: 1920, Rotation: "left"},
 	}
+	i3Run := mcRun(
+		`[{"num":1,"name":"1","visible":true,"focused":false,"output":"HDMI-1"},`,
+		`{"num":2,"name":"2","visible":true,"focused":true,"output":"eDP-1"},`,
+		`{"num":5,"name":"5","visible":false,"focused":false,"output":"eDP-1"}]`,
+	)
 
 	for _, test := range []struct {
-		name string
-		w    *Workspace
-		etc  *command.ExecuteTestCase
-		want *Workspace
+		name    string
+		w       *Workspace
+		etc     *command.ExecuteTestCase
+		want    *Workspace
+		wantEnv map[string]string
+		// wantJournal is only checked if set since most settings changes are
+		// journaled.
+		wantJournal *Journal
 	}{
 		{
 			name: "requires argument",
@@ -57,40 +125,63 @@ func TestWorkspace(t *testing.T) {
 		{
 			name: "requires valid argument",
 			etc: &command.ExecuteTestCase{
-				Args:       []string{"up"},
-				WantErr:    fmt.Errorf(`strconv.Atoi: parsing "up": invalid syntax`),
-				WantStderr: "strconv.Atoi: parsing \"up\": invalid syntax\n",
+				Args:            []string{"up"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1)},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf(`invalid workspace "up": must be N, +N, -N, ^, $, last, or $-N`),
+				WantStderr:      "invalid workspace \"up\": must be N, +N, -N, ^, $, last, or $-N\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "up",
+						"desktops":   numberedDesktops(4, 1),
+					},
+				},
 			},
 		},
 		{
-			name: "fails if runInt fails when getting the number of workspaces",
+			name: "fails if wmctrl fails",
 			etc: &command.ExecuteTestCase{
 				RunResponses:    []*command.FakeRun{errRun("unlimited workspaces")},
 				Args:            []string{"left"},
 				WantErr:         fmt.Errorf("failed to execute bash command: unlimited workspaces"),
 				WantStderr:      "failed to execute bash command: unlimited workspaces\n",
-				WantRunContents: [][]string{numW},
+				WantRunContents: [][]string{dCmd},
+			},
+		},
+		{
+			name: "fails if desktops can't be parsed",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"left"},
+				WantErr:         fmt.Errorf(`failed to parse wmctrl desktop line: "0 * main"`),
+				WantStderr:      "failed to parse wmctrl desktop line: \"0 * main\"\n",
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": []string{"0 * main"},
+					},
+				},
+				RunResponses: []*command.FakeRun{mcRun("0 * main")},
 			},
 		},
 		{
-			name: "fails if runInt fails when getting the current workspace",
+			name: "fails if no workspace is current",
 			etc: &command.ExecuteTestCase{
 				Args:            []string{"left"},
-				WantErr:         fmt.Errorf("failed to execute bash command: unknown workspace"),
-				WantStderr:      "failed to execute bash command: unknown workspace\n",
-				WantRunContents: [][]string{numW, cw},
+				WantErr:         ErrNoCurrentWorkspace,
+				WantStderr:      "couldn't get current workspace\n",
+				WantRunContents: [][]string{dCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces": 1,
+						"desktops": numberedDesktops(4, -1),
 					},
 				},
-				RunResponses: []*command.FakeRun{nRun(1), errRun("unknown workspace")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, -1)},
 			},
 		},
 		{
 			name: "moves left",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(2), mcRun("DP-1")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("DP-1")},
 				Args:         []string{"left"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -98,22 +189,21 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output DP-1 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 2,
+						"desktops": numberedDesktops(4, 2),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 2,
+				Prev: intPtr(2),
 			},
 		},
 		{
 			name: "moves left from 0 to top",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(0), mcRun("DP-2")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 0), mcRun("DP-2")},
 				Args:         []string{"left"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -121,27 +211,26 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output DP-2 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 0,
+						"desktops": numberedDesktops(4, 0),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 0,
+				Prev: intPtr(0),
 			},
 		},
 		{
 			name: "left move changes brightness with trimmed arguments",
 			w: &Workspace{
-				Brightness: map[int]int{
-					1: 37,
+				Profiles: map[int]*Profile{
+					1: {Brightness: 37},
 				},
 			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(2), mcRun("  DP-1\t", "DP-7  ")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("  DP-1\t", "DP-7  ")},
 				Args:         []string{"left"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -150,25 +239,24 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output DP-7 --brightness 0.37",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 2,
+						"desktops": numberedDesktops(4, 2),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 2,
-				Brightness: map[int]int{
-					1: 37,
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 37},
 				},
 			},
 		},
 		{
 			name: "moves right",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(1), mcRun("eDP-9")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("eDP-9")},
 				Args:         []string{"right"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -176,22 +264,21 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output eDP-9 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 1,
+						"desktops": numberedDesktops(4, 1),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 1,
+				Prev: intPtr(1),
 			},
 		},
 		{
 			name: "moves right from last workspace",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(3), mcRun("dp1", "dp2", "dp3", "dp4")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 3), mcRun("dp1", "dp2", "dp3", "dp4")},
 				Args:         []string{"right"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -202,27 +289,26 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output dp4 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 3,
+						"desktops": numberedDesktops(4, 3),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 3,
+				Prev: intPtr(3),
 			},
 		},
 		{
 			name: "right move changes brightness",
 			w: &Workspace{
-				Brightness: map[int]int{
-					0: 101,
+				Profiles: map[int]*Profile{
+					0: {Brightness: 101},
 				},
 			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), nRun(3), mcRun("DP-1", "DP-7")},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 3), mcRun("DP-1", "DP-7")},
 				Args:         []string{"right"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -231,30 +317,29 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output DP-7 --brightness 1.01",
 					},
 				},
-				WantRunContents: [][]string{numW, cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"numWorkspaces":    4,
-						"currentWorkspace": 3,
+						"desktops": numberedDesktops(4, 3),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 3,
-				Brightness: map[int]int{
-					0: 101,
+				Prev: intPtr(3),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 101},
 				},
 			},
 		},
 		{
 			name: "moves to nth workspace",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(5), mcRun()},
+				RunResponses: []*command.FakeRun{desktopsRun(6, 5), mcRun()},
 				Args:         []string{"3"},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:       3,
-						"currentWorkspace": 5,
+						workspaceArg: "3",
+						"desktops":   numberedDesktops(6, 5),
 					},
 				},
 				WantExecuteData: &command.ExecuteData{
@@ -262,26 +347,26 @@ func TestWorkspace(t *testing.T) {
 						"wmctrl -s 3",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 			},
 			want: &Workspace{
-				Prev: 5,
+				Prev: intPtr(5),
 			},
 		},
 		{
 			name: "nth move changes brightness",
 			w: &Workspace{
-				Brightness: map[int]int{
-					3: 21,
+				Profiles: map[int]*Profile{
+					3: {Brightness: 21},
 				},
 			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(5), mcRun("DP-2", "DP-5")},
+				RunResponses: []*command.FakeRun{desktopsRun(6, 5), mcRun("DP-2", "DP-5")},
 				Args:         []string{"3"},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:       3,
-						"currentWorkspace": 5,
+						workspaceArg: "3",
+						"desktops":   numberedDesktops(6, 5),
 					},
 				},
 				WantExecuteData: &command.ExecuteData{
@@ -291,36 +376,36 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output DP-5 --brightness 0.21",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 			},
 			want: &Workspace{
-				Prev: 5,
-				Brightness: map[int]int{
-					3: 21,
+				Prev: intPtr(5),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 21},
 				},
 			},
 		},
 		{
 			name: "does nothing if request to move to same workspace",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(2)},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2)},
 				Args:         []string{"2"},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:       2,
-						"currentWorkspace": 2,
+						workspaceArg: "2",
+						"desktops":   numberedDesktops(4, 2),
 					},
 				},
-				WantRunContents: [][]string{cw},
+				WantRunContents: [][]string{dCmd},
 			},
 		},
 		{
 			name: "moves back a workspace",
 			w: &Workspace{
-				Prev: 3,
+				Prev: intPtr(3),
 			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(5), mcRun("dp0")},
+				RunResponses: []*command.FakeRun{desktopsRun(6, 5), mcRun("dp0")},
 				Args:         []string{"back"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -328,27 +413,27 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output dp0 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 5,
+						"desktops": numberedDesktops(6, 5),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 5,
+				Prev: intPtr(5),
 			},
 		},
 		{
 			name: "moves back a workspace changes brightness",
 			w: &Workspace{
-				Prev: 3,
-				Brightness: map[int]int{
-					3: 45,
+				Prev: intPtr(3),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 45},
 				},
 			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(5), mcRun("eDP-3")},
+				RunResponses: []*command.FakeRun{desktopsRun(6, 5), mcRun("eDP-3")},
 				Args:         []string{"back"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
@@ -356,250 +441,2363 @@ func TestWorkspace(t *testing.T) {
 						"xrandr --output eDP-3 --brightness 0.45",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 5,
+						"desktops": numberedDesktops(6, 5),
 					},
 				},
 			},
 			want: &Workspace{
-				Prev: 5,
-				Brightness: map[int]int{
-					3: 45,
+				Prev: intPtr(5),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 45},
 				},
 			},
 		},
-		// List monitors
+		// Dry run
 		{
-			name: "Lists monitors",
+			name: "dry run move prints executables and state changes",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 21},
+				},
+			},
 			etc: &command.ExecuteTestCase{
-				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "DP-1-3")},
-				Args:            []string{"monitors", "list"},
-				WantRunContents: [][]string{lmCmd},
+				RunResponses: []*command.FakeRun{desktopsRun(6, 5), mcRun("DP-2")},
+				Args:         []string{"3", "--dry-run"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "3",
+						"desktops":   numberedDesktops(6, 5),
+						"dry-run":    true,
+					},
+				},
 				WantStdout: strings.Join([]string{
-					"DP-1-3",
-					"eDP-1",
+					"Would run:",
+					"  wmctrl -s 3",
+					"  xrandr --output DP-2 --brightness 0.21",
+					"Would change:",
+					"  Prev: <unset> -> 5",
 					"",
 				}, "\n"),
+				WantRunContents: [][]string{dCmd, lmCmd},
+			},
+		},
+		{
+			name: "dry run move to same workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2)},
+				Args:         []string{"-n", "2"},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"mcs": []string{"DP-1-3", "eDP-1"},
+						workspaceArg: "2",
+						"desktops":   numberedDesktops(4, 2),
+						"dry-run":    true,
 					},
 				},
+				WantStdout: strings.Join([]string{
+					"Would not run anything",
+					"Would not change any saved state",
+					"",
+				}, "\n"),
+				WantRunContents: [][]string{dCmd},
 			},
 		},
-		// Set brightness
 		{
-			name: "Adds brightness to nil map",
+			name: "dry run brightness set",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+				},
+			},
 			etc: &command.ExecuteTestCase{
-				Args: []string{"brightness", "set", "3", "75"},
+				Args: []string{"brightness", "set", "3", "60", "-n"},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:  3,
-						brightnessArg: 75,
+						workspaceArg:  "3",
+						brightnessArg: 60,
+						"dry-run":     true,
 					},
 				},
+				WantStdout: strings.Join([]string{
+					"Would not run anything",
+					"Would change:",
+					"  Profiles.3.Brightness: 75 -> 60",
+					"",
+				}, "\n"),
 			},
-			want: &Workspace{
-				Brightness: map[int]int{
-					3: 75,
+		},
+		{
+			name: "dry run brightness up",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 4), mcRun("eDP-9")},
+				Args:         []string{"brightness", "up", "--dry-run"},
+				WantStdout: strings.Join([]string{
+					"Would run:",
+					"  xrandr --output eDP-9 --brightness 1.10",
+					"Would change:",
+					"  Profiles.4.Brightness: <unset> -> 110",
+					"",
+				}, "\n"),
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(5, 4),
+						"mcs":      []string{"eDP-9"},
+						"dry-run":  true,
+					},
 				},
 			},
 		},
+		// Workspace addressing
 		{
-			name: "Adds brightness",
-			w: &Workspace{
-				Brightness: map[int]int{
-					3: 75,
+			name: "moves to relative workspace on the right",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("DP-1")},
+				Args:         []string{"+2"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 3",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "+2",
+						"desktops":   numberedDesktops(4, 1),
+					},
 				},
 			},
+			want: &Workspace{
+				Prev: intPtr(1),
+			},
+		},
+		{
+			name: "moves to relative workspace on the left with wrapping",
 			etc: &command.ExecuteTestCase{
-				Args: []string{"brightness", "set", "8", "222"},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("DP-1")},
+				Args:         []string{"-3"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 2",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:  8,
-						brightnessArg: 222,
+						workspaceArg: "-3",
+						"desktops":   numberedDesktops(4, 1),
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					3: 75,
-					8: 222,
+				Prev: intPtr(1),
+			},
+		},
+		{
+			name: "moves to first workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("DP-1")},
+				Args:         []string{"^"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 0",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "^",
+						"desktops":   numberedDesktops(4, 2),
+					},
 				},
 			},
+			want: &Workspace{
+				Prev: intPtr(2),
+			},
 		},
 		{
-			name: "Overwrites brightness",
-			w: &Workspace{
-				Brightness: map[int]int{
-					3: 75,
-					8: 222,
+			name: "moves to last workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 0), mcRun("DP-1")},
+				Args:         []string{"$"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 4",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "$",
+						"desktops":   numberedDesktops(5, 0),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(0),
+			},
+		},
+		{
+			name: "moves to last workspace by name",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(3, 0), mcRun("DP-1")},
+				Args:         []string{"last"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 2",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "last",
+						"desktops":   numberedDesktops(3, 0),
+					},
 				},
 			},
+			want: &Workspace{
+				Prev: intPtr(0),
+			},
+		},
+		{
+			name: "moves to workspace counted from the end",
 			etc: &command.ExecuteTestCase{
-				Args: []string{"brightness", "set", "8", "90"},
+				RunResponses: []*command.FakeRun{desktopsRun(5, 0), mcRun("DP-1")},
+				Args:         []string{"$-1"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 3",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						workspaceArg:  8,
-						brightnessArg: 90,
+						workspaceArg: "$-1",
+						"desktops":   numberedDesktops(5, 0),
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					3: 75,
-					8: 90,
+				Prev: intPtr(0),
+			},
+		},
+		{
+			name: "fails if counted from the end past the first workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(3, 0)},
+				Args:            []string{"last-3"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf(`workspace "last-3" does not exist (only 3 workspaces)`),
+				WantStderr:      "workspace \"last-3\" does not exist (only 3 workspaces)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "last-3",
+						"desktops":   numberedDesktops(3, 0),
+					},
 				},
 			},
 		},
 		{
-			name: "Lists brightness",
-			w: &Workspace{
-				Brightness: map[int]int{
-					3:  75,
-					8:  222,
-					24: 68,
+			name: "fails if workspace does not exist",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1)},
+				Args:            []string{"7"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf("workspace 7 does not exist (only 4 workspaces)"),
+				WantStderr:      "workspace 7 does not exist (only 4 workspaces)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "7",
+						"desktops":   numberedDesktops(4, 1),
+					},
 				},
 			},
+		},
+		{
+			name: "fails if end offset is invalid",
 			etc: &command.ExecuteTestCase{
-				Args: []string{"brightness", "list"},
-				WantStdout: strings.Join([]string{
-					" 3: 75",
-					" 8: 222",
-					"24: 68",
-					"",
-				}, "\n"),
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 0)},
+				Args:            []string{"$-x"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf(`invalid workspace "$-x": must be N, +N, -N, ^, $, last, or $-N`),
+				WantStderr:      "invalid workspace \"$-x\": must be N, +N, -N, ^, $, last, or $-N\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "$-x",
+						"desktops":   numberedDesktops(4, 0),
+					},
+				},
 			},
 		},
-		// Increase brightness
 		{
-			name: "Increase brightness when none set",
+			name: "sets brightness for last workspace",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), mcRun("eDP-9", "other")},
-				Args:         []string{"brightness", "up"},
-				WantExecuteData: &command.ExecuteData{
-					Executable: []string{
-						"xrandr --output eDP-9 --brightness 1.10",
-						"xrandr --output other --brightness 1.10",
+				RunResponses:    []*command.FakeRun{desktopsRun(6, 0)},
+				Args:            []string{"brightness", "set", "$", "75"},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "$",
+						brightnessArg: 75,
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					5: {Brightness: 75},
+				},
+			},
+		},
+		{
+			name: "sets brightness for relative workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(6, 5)},
+				Args:            []string{"brightness", "set", "+1", "75"},
+				WantRunContents: [][]string{dCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 4,
-						"mcs":              []string{"eDP-9", "other"},
+						workspaceArg:  "+1",
+						brightnessArg: 75,
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					4: 110,
+				Profiles: map[int]*Profile{
+					0: {Brightness: 75},
 				},
 			},
 		},
+		// Occupancy
 		{
-			name: "Increase brightness when already set",
-			w: &Workspace{
-				Brightness: map[int]int{
-					4: 70,
+			name: "moves to next occupied workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 1), mcRun("0x01  0 host a", "0x02  3 host b"), mcRun("DP-1")},
+				Args:         []string{"next-occupied"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 3",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, wCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(5, 1),
+						"windows":  []string{"0x01  0 host a", "0x02  3 host b"},
+					},
 				},
 			},
+			want: &Workspace{
+				Prev: intPtr(1),
+			},
+		},
+		{
+			name: "moves to previous occupied workspace with wrapping",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(4), mcRun("eDP-9", "other")},
-				Args:         []string{"brightness", "up"},
+				RunResponses: []*command.FakeRun{desktopsRun(5, 1), mcRun("0x01  1 host a", "0x02  3 host b", "0x03 -1 host panel"), mcRun("DP-1")},
+				Args:         []string{"prev-occupied"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
-						"xrandr --output eDP-9 --brightness 0.80",
-						"xrandr --output other --brightness 0.80",
+						"wmctrl -s 3",
+						"xrandr --output DP-1 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, wCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 4,
-						"mcs":              []string{"eDP-9", "other"},
+						"desktops": numberedDesktops(5, 1),
+						"windows":  []string{"0x01  1 host a", "0x02  3 host b", "0x03 -1 host panel"},
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					4: 80,
+				Prev: intPtr(1),
+			},
+		},
+		{
+			name: "fails if no other workspace is occupied",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(3, 1), mcRun("0x01  1 host a")},
+				Args:            []string{"next-occupied"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantErr:         fmt.Errorf("no other workspaces have any windows"),
+				WantStderr:      "no other workspaces have any windows\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(3, 1),
+						"windows":  []string{"0x01  1 host a"},
+					},
 				},
 			},
 		},
 		{
-			name: "Decrease brightness when none set",
+			name: "moves to first empty workspace",
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(1), mcRun("eDP-9", "other")},
-				Args:         []string{"brightness", "down"},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 0), mcRun("0x01  0 host a", "0x02  1 host b"), mcRun("DP-1")},
+				Args:         []string{"empty"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
-						"xrandr --output eDP-9 --brightness 0.90",
-						"xrandr --output other --brightness 0.90",
+						"wmctrl -s 2",
+						"xrandr --output DP-1 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, wCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 1,
-						"mcs":              []string{"eDP-9", "other"},
+						"desktops": numberedDesktops(4, 0),
+						"windows":  []string{"0x01  0 host a", "0x02  1 host b"},
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					1: 90,
+				Prev: intPtr(0),
+			},
+		},
+		{
+			name: "fails if no workspace is empty",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(2, 0), mcRun("0x01  0 host a", "0x02  1 host b")},
+				Args:            []string{"empty"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantErr:         fmt.Errorf("all 2 workspaces have windows"),
+				WantStderr:      "all 2 workspaces have windows\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(2, 0),
+						"windows":  []string{"0x01  0 host a", "0x02  1 host b"},
+					},
 				},
 			},
 		},
 		{
-			name: "Decrease brightness when already set",
-			w: &Workspace{
-				Brightness: map[int]int{
-					2: 70,
-					4: 111,
+			name: "enables skipping empty workspaces",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"skip-empty", "true"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						skipEmptyArg: true,
+					},
 				},
 			},
+			want: &Workspace{
+				SkipEmpty: true,
+			},
+		},
+		{
+			name: "left skips empty workspaces",
+			w: &Workspace{
+				SkipEmpty: true,
+			},
 			etc: &command.ExecuteTestCase{
-				RunResponses: []*command.FakeRun{nRun(2), mcRun("eDP-9")},
-				Args:         []string{"brightness", "down"},
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("0x01  0 host a"), mcRun("DP-1")},
+				Args:         []string{"left"},
 				WantExecuteData: &command.ExecuteData{
 					Executable: []string{
-						"xrandr --output eDP-9 --brightness 0.60",
+						"wmctrl -s 0",
+						"xrandr --output DP-1 --brightness 1.00",
 					},
 				},
-				WantRunContents: [][]string{cw, lmCmd},
+				WantRunContents: [][]string{dCmd, wCmd, lmCmd},
 				WantData: &command.Data{
 					Values: map[string]interface{}{
-						"currentWorkspace": 2,
-						"mcs":              []string{"eDP-9"},
+						"desktops": numberedDesktops(4, 2),
 					},
 				},
 			},
 			want: &Workspace{
-				Brightness: map[int]int{
-					2: 60,
-					4: 111,
-				},
+				Prev:      intPtr(2),
+				SkipEmpty: true,
+			},
+		},
+		{
+			name: "right moves normally if all other workspaces are empty",
+			w: &Workspace{
+				SkipEmpty: true,
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("0x01  2 host a"), mcRun("DP-1")},
+				Args:         []string{"right"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 3",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, wCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 2),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev:      intPtr(2),
+				SkipEmpty: true,
+			},
+		},
+		// Swap and reorder
+		{
+			name: "swaps workspaces",
+			w: &Workspace{
+				Prev: intPtr(3),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+					3: {Brightness: 80},
+					4: {Brightness: 55},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 1), mcRun("0x01  1 host a", "0x02  3 host b", "0x03 -1 host panel", "0x04  0 host c")},
+				Args:         []string{"swap", "1", "3"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -i -r 0x01 -t 3",
+						"wmctrl -i -r 0x02 -t 1",
+						"wmctrl -s 3",
+					},
+				},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						swapAArg:   "1",
+						swapBArg:   "3",
+						"desktops": numberedDesktops(5, 1),
+						"windows":  []string{"0x01  1 host a", "0x02  3 host b", "0x03 -1 host panel", "0x04  0 host c"},
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 80},
+					3: {Brightness: 40},
+					4: {Brightness: 55},
+				},
+			},
+		},
+		{
+			name: "swap fails for nonexistent workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1), mcRun("0x01  1 host a")},
+				Args:            []string{"swap", "1", "9"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantErr:         fmt.Errorf("workspace 9 does not exist (only 4 workspaces)"),
+				WantStderr:      "workspace 9 does not exist (only 4 workspaces)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						swapAArg:   "1",
+						swapBArg:   "9",
+						"desktops": numberedDesktops(4, 1),
+						"windows":  []string{"0x01  1 host a"},
+					},
+				},
+			},
+		},
+		{
+			name: "moves workspace to a later position",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 30},
+					3: {Brightness: 70},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("0x01  0 host a", "0x02  2 host b", "0x03  3 host c")},
+				Args:         []string{"move-to", "0", "2"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -i -r 0x01 -t 2",
+						"wmctrl -i -r 0x02 -t 1",
+						"wmctrl -s 1",
+					},
+				},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						moveFromArg: "0",
+						moveToArg:   "2",
+						"desktops":  numberedDesktops(4, 2),
+						"windows":   []string{"0x01  0 host a", "0x02  2 host b", "0x03  3 host c"},
+					},
+				},
+			},
+			want: &Workspace{
+				ScratchReturn: 2,
+				Profiles: map[int]*Profile{
+					2: {Brightness: 30},
+					3: {Brightness: 70},
+				},
+			},
+		},
+		{
+			name: "moves workspace to an earlier position",
+			w: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					3: {Brightness: 70},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 0), mcRun("0x01  1 host a", "0x02  3 host b")},
+				Args:         []string{"move-to", "$", "1"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -i -r 0x01 -t 2",
+						"wmctrl -i -r 0x02 -t 1",
+					},
+				},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						moveFromArg: "$",
+						moveToArg:   "1",
+						"desktops":  numberedDesktops(4, 0),
+						"windows":   []string{"0x01  1 host a", "0x02  3 host b"},
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 70},
+				},
+			},
+		},
+		// Scratch
+		{
+			name: "toggles to default scratch workspace",
+			w: &Workspace{
+				Prev: intPtr(3),
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 1), mcRun("DP-1")},
+				Args:         []string{"scratch"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 4",
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(5, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev:          intPtr(3),
+				ScratchReturn: 1,
+			},
+		},
+		{
+			name: "toggles back from scratch workspace",
+			w: &Workspace{
+				Prev:          intPtr(3),
+				Scratch:       intPtr(2),
+				ScratchReturn: 1,
+				Profiles: map[int]*Profile{
+					1: {Brightness: 60},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("DP-1")},
+				Args:         []string{"scratch"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 1",
+						"xrandr --output DP-1 --brightness 0.60",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 2),
+					},
+				},
+			},
+		},
+		{
+			name: "does nothing if scratch workspace returns to itself",
+			w: &Workspace{
+				Scratch:       intPtr(2),
+				ScratchReturn: 2,
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 2)},
+				Args:            []string{"scratch"},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 2),
+					},
+				},
+			},
+		},
+		{
+			name: "sets scratch workspace and brightness",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"scratch", "set", "6", "40"},
+				RunResponses:    []*command.FakeRun{desktopsRun(8, 0)},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "6",
+						brightnessArg: 40,
+						"desktops":    numberedDesktops(8, 0),
+					},
+				},
+			},
+			want: &Workspace{
+				Scratch: intPtr(6),
+				Profiles: map[int]*Profile{
+					6: {Brightness: 40},
+				},
+			},
+		},
+		{
+			name: "sets scratch workspace without brightness",
+			w: &Workspace{
+				Scratch: intPtr(6),
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"scratch", "set", "^"},
+				RunResponses:    []*command.FakeRun{desktopsRun(3, 1)},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "^",
+						"desktops":   numberedDesktops(3, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Scratch: intPtr(0),
+			},
+		},
+		{
+			name: "fails to set scratch workspace that doesn't exist",
+			w: &Workspace{
+				Scratch: intPtr(1),
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"scratch", "set", "5"},
+				RunResponses:    []*command.FakeRun{desktopsRun(3, 1)},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf("workspace 5 does not exist (only 3 workspaces)"),
+				WantStderr:      "workspace 5 does not exist (only 3 workspaces)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "5",
+						"desktops":   numberedDesktops(3, 1),
+					},
+				},
+			},
+		},
+		// List monitors
+		{
+			name: "Lists monitors",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "DP-1-3")},
+				Args:            []string{"monitors", "list"},
+				WantRunContents: [][]string{lmCmd},
+				WantStdout: strings.Join([]string{
+					"DP-1-3",
+					"eDP-1",
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"mcs": []string{"DP-1-3", "eDP-1"},
+					},
+				},
+			},
+		},
+		{
+			name: "Lists locked monitors",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{mcRun("eDP-1", "HDMI-1", "DP-1-3")},
+				Args:            []string{"monitors", "list"},
+				WantRunContents: [][]string{lmCmd},
+				WantStdout: strings.Join([]string{
+					"DP-1-3",
+					"HDMI-1 (locked)",
+					"eDP-1",
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"mcs": []string{"DP-1-3", "HDMI-1", "eDP-1"},
+					},
+				},
+			},
+		},
+		// Per-monitor workspaces
+		{
+			name: "enables per-monitor workspaces",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"per-monitor", "true"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						perMonitorArg: true,
+					},
+				},
+			},
+			want: &Workspace{
+				PerMonitor: true,
+			},
+		},
+		{
+			name: "right moves on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+				// i3 names each desktop after its workspace, so desktop 2 is
+				// workspace number 5.
+				Profiles: map[int]*Profile{
+					2: {Brightness: 30},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopRun(1, "1", "2", "5"), i3Run},
+				Args:         []string{"right"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"i3-msg workspace number 5",
+						"xrandr --output eDP-1 --brightness 0.30",
+					},
+				},
+				WantRunContents: [][]string{dCmd, i3Cmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": desktopLines(1, "1", "2", "5"),
+					},
+				},
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 30},
+				},
+			},
+		},
+		{
+			name: "moves to last workspace on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopRun(1, "1", "2", "5"), i3Run},
+				Args:         []string{"$"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"i3-msg workspace number 5",
+						"xrandr --output eDP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, i3Cmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "$",
+						"desktops":   desktopLines(1, "1", "2", "5"),
+					},
+				},
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+			},
+		},
+		{
+			name: "fails to move past the workspaces on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(1, "1", "2", "5"), i3Run},
+				Args:            []string{"2"},
+				WantRunContents: [][]string{dCmd, i3Cmd},
+				WantErr:         fmt.Errorf("workspace 2 does not exist (only 2 workspaces)"),
+				WantStderr:      "workspace 2 does not exist (only 2 workspaces)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "2",
+						"desktops":   desktopLines(1, "1", "2", "5"),
+					},
+				},
+			},
+		},
+		{
+			name: "moves back on the focused monitor",
+			w: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 5,
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopRun(1, "1", "2", "5"), i3Run},
+				Args:         []string{"back"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"i3-msg workspace number 5",
+						"xrandr --output eDP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, i3Cmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": desktopLines(1, "1", "2", "5"),
+					},
+				},
+			},
+			want: &Workspace{
+				PerMonitor: true,
+				MonitorPrev: map[string]int{
+					"eDP-1": 2,
+				},
+			},
+		},
+		{
+			name: "focuses monitor on the right",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"focus-monitor", "right"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"i3-msg focus output right",
+					},
+				},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						directionArg: "right",
+					},
+				},
+			},
+		},
+		// Locked monitors
+		{
+			name: "Locks monitor",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"monitors", "lock", "DP-2"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						monitorArg: "DP-2",
+					},
+				},
+			},
+			want: &Workspace{
+				LockedMonitors: []string{"DP-2", "HDMI-1"},
+			},
+		},
+		{
+			name: "Locking a locked monitor does nothing",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"monitors", "lock", "HDMI-1"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						monitorArg: "HDMI-1",
+					},
+				},
+			},
+		},
+		{
+			name: "Unlocks monitor",
+			w: &Workspace{
+				LockedMonitors: []string{"DP-2", "HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"monitors", "unlock", "DP-2"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						monitorArg: "DP-2",
+					},
+				},
+			},
+			want: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+		},
+		{
+			name: "Fails to unlock monitor that isn't locked",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"monitors", "unlock", "DP-2"},
+				WantErr:    fmt.Errorf(`monitor "DP-2" is not locked`),
+				WantStderr: "monitor \"DP-2\" is not locked\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						monitorArg: "DP-2",
+					},
+				},
+			},
+		},
+		{
+			name: "Moving workspaces skips locked monitors",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 50},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("eDP-1", "HDMI-1")},
+				Args:         []string{"right"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"wmctrl -s 2",
+						"xrandr --output eDP-1 --brightness 0.50",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev:           intPtr(1),
+				LockedMonitors: []string{"HDMI-1"},
+				Profiles: map[int]*Profile{
+					2: {Brightness: 50},
+				},
+			},
+		},
+		{
+			name: "Brightness up skips locked monitors",
+			w: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 0), mcRun("HDMI-1", "eDP-1")},
+				Args:         []string{"brightness", "up"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-1 --brightness 1.10",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 0),
+						"mcs":      []string{"HDMI-1", "eDP-1"},
+					},
+				},
+			},
+			want: &Workspace{
+				LockedMonitors: []string{"HDMI-1"},
+				Profiles: map[int]*Profile{
+					0: {Brightness: 110},
+				},
+			},
+		},
+		// Set brightness
+		{
+			name: "Adds brightness to nil map",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "set", "3", "75"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "3",
+						brightnessArg: 75,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+				},
+			},
+		},
+		{
+			name: "Adds brightness",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "set", "8", "222"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "8",
+						brightnessArg: 222,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+					8: {Brightness: 222},
+				},
+			},
+		},
+		{
+			name: "Overwrites brightness",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+					8: {Brightness: 222},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "set", "8", "90"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "8",
+						brightnessArg: 90,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					3: {Brightness: 75},
+					8: {Brightness: 90},
+				},
+			},
+		},
+		{
+			name: "Lists brightness",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					3:  {Brightness: 75},
+					8:  {Brightness: 222},
+					24: {Brightness: 68},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "list"},
+				WantStdout: strings.Join([]string{
+					" 3: 75",
+					" 8: 222",
+					"24: 68",
+					"",
+				}, "\n"),
+			},
+		},
+		// Undo and redo
+		{
+			name: "journals brightness change",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "set", "1", "60"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg:  "1",
+						brightnessArg: 60,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 60},
+				},
+			},
+			wantJournal: &Journal{
+				Undo: []*Settings{{
+					Profiles: map[int]*Profile{
+						1: {Brightness: 40},
+					},
+				}},
+			},
+		},
+		{
+			name: "undoes brightness change to the current workspace",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 60},
+				},
+				Journal: &Journal{
+					Undo: []*Settings{{
+						Profiles: map[int]*Profile{
+							1: {Brightness: 40},
+						},
+					}},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("DP-1")},
+				Args:         []string{"undo"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output DP-1 --brightness 0.40",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			wantJournal: &Journal{
+				Redo: []*Settings{{
+					Profiles: map[int]*Profile{
+						1: {Brightness: 60},
+					},
+				}},
+			},
+		},
+		{
+			name: "redoes change to another workspace",
+			w: &Workspace{
+				Journal: &Journal{
+					Redo: []*Settings{{
+						SkipEmpty: true,
+						Profiles: map[int]*Profile{
+							3: {Brightness: 50},
+						},
+					}},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1)},
+				Args:            []string{"redo"},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				SkipEmpty: true,
+				Profiles: map[int]*Profile{
+					3: {Brightness: 50},
+				},
+			},
+			wantJournal: &Journal{
+				Undo: []*Settings{{}},
+			},
+		},
+		{
+			name: "fails to undo without changes",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1)},
+				Args:            []string{"undo"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf("nothing to undo"),
+				WantStderr:      "nothing to undo\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+		},
+		// Brightness presets
+		{
+			name: "saves brightness preset",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "preset", "save", "reading", "55"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg:     "reading",
+						brightnessArg: 55,
+					},
+				},
+			},
+			want: &Workspace{
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+				},
+			},
+		},
+		{
+			name: "saves every workspace's brightness",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 90},
+					3: {Brightness: 40},
+					4: {},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "preset", "save", "day"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg: "day",
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 90},
+					3: {Brightness: 40},
+					4: {},
+				},
+				Presets: map[string]*Preset{
+					"day": {Workspaces: map[int]int{0: 90, 3: 40}},
+				},
+			},
+		},
+		{
+			name: "applies brightness preset to the current workspace",
+			w: &Workspace{
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("DP-1")},
+				Args:         []string{"brightness", "preset", "apply", "reading"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output DP-1 --brightness 0.55",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg: "reading",
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 55},
+				},
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+				},
+			},
+		},
+		{
+			name: "applies brightness preset to all workspaces",
+			w: &Workspace{
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(2, 1), mcRun("DP-1")},
+				Args:         []string{"brightness", "preset", "apply", "reading", "all"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output DP-1 --brightness 0.55",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg:       "reading",
+						presetTargetArg: "all",
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 55},
+					1: {Brightness: 55},
+				},
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+				},
+			},
+		},
+		{
+			name: "applies mapping preset",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 90},
+					1: {Brightness: 70},
+				},
+				Presets: map[string]*Preset{
+					"night": {Workspaces: map[int]int{0: 30, 2: 20}},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("DP-1")},
+				Args:         []string{"brightness", "preset", "apply", "night"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output DP-1 --brightness 1.00",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg: "night",
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 30},
+					1: {},
+					2: {Brightness: 20},
+				},
+				Presets: map[string]*Preset{
+					"night": {Workspaces: map[int]int{0: 30, 2: 20}},
+				},
+			},
+		},
+		{
+			name: "fails to apply unknown preset",
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "preset", "apply", "night"},
+				WantErr:    fmt.Errorf(`preset "night" does not exist`),
+				WantStderr: "preset \"night\" does not exist\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						presetArg: "night",
+					},
+				},
+			},
+		},
+		{
+			name: "lists presets",
+			w: &Workspace{
+				Presets: map[string]*Preset{
+					"reading": {Brightness: 55},
+					"night":   {Workspaces: map[int]int{0: 30, 2: 20}},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "preset", "list"},
+				WantStdout: strings.Join([]string{
+					"night: 0=30 2=20",
+					"reading: 55",
+					"",
+				}, "\n"),
+			},
+		},
+		// Presentation mode
+		{
+			name: "presents on projector",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+				Rules: []*BrightnessRule{
+					{Pattern: "term", Brightness: 30},
+				},
+				PresentationWorkspace: intPtr(3),
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1), xqRun, mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{dCmd, xqCmd, lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output HDMI-1 --auto --same-as eDP-1",
+						"wmctrl -s 3",
+						"xrandr --output eDP-1 --brightness 1.00",
+						"xrandr --output HDMI-1 --brightness 1.00",
+					},
+				},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+						"xrandr":   xrandrLines,
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 100},
+					1: {Brightness: 100},
+					2: {Brightness: 100},
+					3: {Brightness: 100},
+				},
+				PresentationWorkspace: intPtr(3),
+				Presentation: &Presentation{
+					Settings: &Settings{
+						Profiles: map[int]*Profile{
+							1: {Brightness: 40},
+						},
+						Rules: []*BrightnessRule{
+							{Pattern: "term", Brightness: 30},
+						},
+						PresentationWorkspace: intPtr(3),
+					},
+					Outputs: presentLayout,
+					Return:  1,
+				},
+			},
+		},
+		{
+			name: "presents on extended output from current workspace",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present", "HDMI-1", "-e"},
+				RunResponses:    []*command.FakeRun{desktopsRun(2, 1), xqRun, mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{dCmd, xqCmd, lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output HDMI-1 --auto --right-of eDP-1",
+						"xrandr --output eDP-1 --brightness 1.00",
+						"xrandr --output HDMI-1 --brightness 1.00",
+					},
+				},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						outputArg:                "HDMI-1",
+						presentExtendFlag.Name(): true,
+						"desktops":               numberedDesktops(2, 1),
+						"xrandr":                 xrandrLines,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 100},
+					1: {Brightness: 100},
+				},
+				Presentation: &Presentation{
+					Settings: &Settings{
+						Profiles: map[int]*Profile{
+							1: {Brightness: 40},
+						},
+					},
+					Outputs: presentLayout,
+					Return:  1,
+				},
+			},
+		},
+		{
+			name: "fails to present on disconnected output",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present", "DP-1"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1), xqRun},
+				WantRunContents: [][]string{dCmd, xqCmd},
+				WantErr:         fmt.Errorf(`output "DP-1" is not connected`),
+				WantStderr:      "output \"DP-1\" is not connected\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						outputArg:  "DP-1",
+						"desktops": numberedDesktops(4, 1),
+						"xrandr":   xrandrLines,
+					},
+				},
+			},
+		},
+		{
+			name: "fails to present if already presenting",
+			w: &Workspace{
+				Presentation: &Presentation{Return: 2},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1), xqRun},
+				WantRunContents: [][]string{dCmd, xqCmd},
+				WantErr:         fmt.Errorf("a presentation is already in progress (run `ws present stop` first)"),
+				WantStderr:      "a presentation is already in progress (run `ws present stop` first)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+						"xrandr":   xrandrLines,
+					},
+				},
+			},
+			want: &Workspace{
+				Presentation: &Presentation{Return: 2},
+			},
+		},
+		{
+			name: "stops presenting",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 100},
+					1: {Brightness: 100},
+					2: {Brightness: 100},
+					3: {Brightness: 100},
+				},
+				PresentationWorkspace: intPtr(3),
+				Presentation: &Presentation{
+					Settings: &Settings{
+						Profiles: map[int]*Profile{
+							1: {Brightness: 40},
+						},
+						Rules: []*BrightnessRule{
+							{Pattern: "term", Brightness: 30},
+						},
+						PresentationWorkspace: intPtr(3),
+					},
+					Outputs: presentLayout,
+					Return:  1,
+					Journal: &Journal{
+						Undo: []*Settings{{}},
+					},
+				},
+				// Changed during the presentation.
+				Journal: &Journal{
+					Undo: []*Settings{
+						{},
+						{Profiles: map[int]*Profile{
+							0: {Brightness: 100},
+							1: {Brightness: 100},
+							2: {Brightness: 100},
+							3: {Brightness: 100},
+						}},
+					},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present", "stop"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 3), mcRun("eDP-1", "HDMI-1")},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-1 --mode 1920x1080 --pos 0x0 --rotate normal --primary --output HDMI-1 --off --output DP-2 --mode 1920x1080 --pos 1920x0 --rotate left",
+						"wmctrl -s 1",
+						"xrandr --output eDP-1 --brightness 0.40",
+						"xrandr --output HDMI-1 --brightness 0.40",
+					},
+				},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 3),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(3),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+				Rules: []*BrightnessRule{
+					{Pattern: "term", Brightness: 30},
+				},
+				PresentationWorkspace: intPtr(3),
+			},
+			wantJournal: &Journal{
+				Undo: []*Settings{{}},
+			},
+		},
+		{
+			name: "fails to undo during a presentation",
+			w: &Workspace{
+				Presentation: &Presentation{Return: 2},
+				Journal: &Journal{
+					Undo: []*Settings{{}},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 1)},
+				Args:            []string{"undo"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf("can't undo during a presentation (run `ws present stop` first)"),
+				WantStderr:      "can't undo during a presentation (run `ws present stop` first)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Presentation: &Presentation{Return: 2},
+			},
+			wantJournal: &Journal{
+				Undo: []*Settings{{}},
+			},
+		},
+		{
+			name: "fails to stop presenting if not presenting",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present", "stop"},
+				RunResponses:    []*command.FakeRun{desktopsRun(4, 3)},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf("no presentation is in progress"),
+				WantStderr:      "no presentation is in progress\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 3),
+					},
+				},
+			},
+		},
+		{
+			name: "sets presentation workspace",
+			etc: &command.ExecuteTestCase{
+				Args:            []string{"present", "workspace", "2"},
+				RunResponses:    []*command.FakeRun{desktopsRun(3, 0)},
+				WantRunContents: [][]string{dCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						workspaceArg: "2",
+						"desktops":   numberedDesktops(3, 0),
+					},
+				},
+			},
+			want: &Workspace{
+				PresentationWorkspace: intPtr(2),
+			},
+		},
+		// Brightness rules
+		{
+			name: "Adds brightness rule",
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "rule", "add", "^mpv$", "100"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						patternArg:    "^mpv$",
+						brightnessArg: 100,
+					},
+				},
+			},
+			want: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+				},
+			},
+		},
+		{
+			name: "Adds title brightness rule after existing rules",
+			w: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "rule", "add", "YouTube", "90", "--title"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						patternArg:    "YouTube",
+						brightnessArg: 90,
+						"title":       true,
+					},
+				},
+			},
+			want: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+					{Pattern: "YouTube", Title: true, Brightness: 90},
+				},
+			},
+		},
+		{
+			name: "Fails to add brightness rule with invalid pattern",
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "rule", "add", "mpv(", "100"},
+				WantErr:    fmt.Errorf("invalid pattern \"mpv(\": error parsing regexp: missing closing ): `mpv(`"),
+				WantStderr: "invalid pattern \"mpv(\": error parsing regexp: missing closing ): `mpv(`\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						patternArg:    "mpv(",
+						brightnessArg: 100,
+					},
+				},
+			},
+		},
+		{
+			name: "Lists brightness rules",
+			w: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+					{Pattern: "YouTube", Title: true, Brightness: 90},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "rule", "list"},
+				WantStdout: strings.Join([]string{
+					` 0: class "^mpv$" -> 100`,
+					` 1: title "YouTube" -> 90`,
+					"",
+				}, "\n"),
+			},
+		},
+		{
+			name: "Removes brightness rule",
+			w: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+					{Pattern: "YouTube", Title: true, Brightness: 90},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "rule", "remove", "0"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						ruleIndexArg: 0,
+					},
+				},
+			},
+			want: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "YouTube", Title: true, Brightness: 90},
+				},
+			},
+		},
+		{
+			name: "Fails to remove nonexistent brightness rule",
+			w: &Workspace{
+				Rules: []*BrightnessRule{
+					{Pattern: "^mpv$", Brightness: 100},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "rule", "remove", "1"},
+				WantErr:    fmt.Errorf("rule 1 does not exist (only 1 rules)"),
+				WantStderr: "rule 1 does not exist (only 1 rules)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						ruleIndexArg: 1,
+					},
+				},
+			},
+		},
+		// Increase brightness
+		{
+			name: "Increase brightness when none set",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 4), mcRun("eDP-9", "other")},
+				Args:         []string{"brightness", "up"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 1.10",
+						"xrandr --output other --brightness 1.10",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(5, 4),
+						"mcs":      []string{"eDP-9", "other"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					4: {Brightness: 110},
+				},
+			},
+		},
+		{
+			name: "Increase brightness when already set",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					4: {Brightness: 70},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(5, 4), mcRun("eDP-9", "other")},
+				Args:         []string{"brightness", "up"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 0.80",
+						"xrandr --output other --brightness 0.80",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(5, 4),
+						"mcs":      []string{"eDP-9", "other"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					4: {Brightness: 80},
+				},
+			},
+		},
+		{
+			name: "Increase brightness stops at the maximum",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 245},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("eDP-9")},
+				Args:         []string{"brightness", "up"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 2.50",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+						"mcs":      []string{"eDP-9"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 250},
+				},
+			},
+		},
+		{
+			name: "Decrease brightness when none set",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("eDP-9", "other")},
+				Args:         []string{"brightness", "down"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 0.90",
+						"xrandr --output other --brightness 0.90",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+						"mcs":      []string{"eDP-9", "other"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 90},
+				},
+			},
+		},
+		{
+			name: "Decrease brightness stops at the minimum",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 10},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("eDP-9")},
+				Args:         []string{"brightness", "down"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 0.05",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 1),
+						"mcs":      []string{"eDP-9"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 5},
+				},
+			},
+		},
+		{
+			name: "Decrease brightness when already set",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 70},
+					4: {Brightness: 111},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 2), mcRun("eDP-9")},
+				Args:         []string{"brightness", "down"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"xrandr --output eDP-9 --brightness 0.60",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"desktops": numberedDesktops(4, 2),
+						"mcs":      []string{"eDP-9"},
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 60},
+					4: {Brightness: 111},
+				},
+			},
+		},
+		// List
+		{
+			name: "Lists workspaces as a table",
+			w: &Workspace{
+				Prev: intPtr(2),
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{
+					desktopRun(1, "main", "web", "code"),
+					mcRun("0x01  0 host a", "0x02  0 host b", "0x03 -1 host panel", "0x04  2 host c d"),
+				},
+				Args:            []string{"list"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantStdout: strings.Join([]string{
+					"   INDEX  NAME  WINDOWS  BRIGHTNESS",
+					"   0      main  2        100 (default)",
+					"*  1      web   0        40",
+					"-  2      code  1        100 (default)",
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "table",
+						"desktops": desktopLines(1, "main", "web", "code"),
+						"windows":  []string{"0x01  0 host a", "0x02  0 host b", "0x03 -1 host panel", "0x04  2 host c d"},
+					},
+				},
+			},
+		},
+		{
+			name: "Lists workspaces without a previous workspace",
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{
+					desktopRun(1, "main", "web"),
+					mcRun("0x01  0 host a"),
+				},
+				Args:            []string{"list"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantStdout: strings.Join([]string{
+					"   INDEX  NAME  WINDOWS  BRIGHTNESS",
+					"   0      main  1        100 (default)",
+					"*  1      web   0        100 (default)",
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "table",
+						"desktops": desktopLines(1, "main", "web"),
+						"windows":  []string{"0x01  0 host a"},
+					},
+				},
+			},
+		},
+		{
+			name: "Lists workspaces as json",
+			w: &Workspace{
+				Prev: intPtr(1),
+				Profiles: map[int]*Profile{
+					0: {Brightness: 100},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{
+					desktopRun(0, "main", "web"),
+					mcRun("0x01  1 host a"),
+				},
+				Args:            []string{"list", "-f", "json"},
+				WantRunContents: [][]string{dCmd, wCmd},
+				WantStdout: strings.Join([]string{
+					`[{"index":0,"name":"main","current":true,"previous":false,"windows":0,"brightness":100,"defaultBrightness":false},{"index":1,"name":"web","current":false,"previous":true,"windows":1,"brightness":100,"defaultBrightness":true}]`,
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "json",
+						"desktops": desktopLines(0, "main", "web"),
+						"windows":  []string{"0x01  1 host a"},
+					},
+				},
+			},
+		},
+		// Status
+		{
+			name: "Prints text status",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(1, "main", "web", "code")},
+				Args:            []string{"status"},
+				WantRunContents: [][]string{dCmd},
+				WantStdout:      "0:main(100%) [1:web(40%)] 2:code(100%)\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "text",
+						"desktops": desktopLines(1, "main", "web", "code"),
+					},
+				},
+			},
+		},
+		{
+			name: "Prints json status",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					0: {Brightness: 55},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(1, "main", "my web")},
+				Args:            []string{"status", "--format", "json"},
+				WantRunContents: [][]string{dCmd},
+				WantStdout: strings.Join([]string{
+					`[{"index":0,"name":"main","current":false,"brightness":55},{"index":1,"name":"my web","current":true,"brightness":100}]`,
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "json",
+						"desktops": desktopLines(1, "main", "my web"),
+					},
+				},
+			},
+		},
+		{
+			name: "Prints waybar status",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					1: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(1, "main", "web")},
+				Args:            []string{"status", "-f", "waybar"},
+				WantRunContents: [][]string{dCmd},
+				WantStdout: strings.Join([]string{
+					`{"text":"1:web","alt":"web","tooltip":"0:main(100%)\\n1:web(40%)","class":"workspace-1","percentage":40}`,
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "waybar",
+						"desktops": desktopLines(1, "main", "web"),
+					},
+				},
+			},
+		},
+		{
+			name: "Prints i3bar status",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{desktopRun(0, "main", "web")},
+				Args:            []string{"status", "-f", "i3bar"},
+				WantRunContents: [][]string{dCmd},
+				WantStdout: strings.Join([]string{
+					`[{"name":"workspace","instance":"0","full_text":"[0:main 100%]"},{"name":"workspace","instance":"1","full_text":"1:web 100%"}]`,
+					"",
+				}, "\n"),
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "i3bar",
+						"desktops": desktopLines(0, "main", "web"),
+					},
+				},
+			},
+		},
+		{
+			name: "Status fails on unparseable desktops",
+			etc: &command.ExecuteTestCase{
+				RunResponses:    []*command.FakeRun{mcRun("nonsense")},
+				Args:            []string{"status"},
+				WantRunContents: [][]string{dCmd},
+				WantErr:         fmt.Errorf(`failed to parse wmctrl desktop line: "nonsense"`),
+				WantStderr:      "failed to parse wmctrl desktop line: \"nonsense\"\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"format":   "text",
+						"desktops": []string{"nonsense"},
+					},
+				},
+			},
+		},
+		// Display
+		{
+			name: "moves right on another display",
+			w: &Workspace{
+				Prev: intPtr(3),
+				Displays: map[string]*Workspace{
+					":1": {
+						Profiles: map[int]*Profile{
+							2: {Brightness: 70},
+						},
+					},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 1), mcRun("VNC-0")},
+				Args:         []string{"right", "--display", ":1"},
+				WantExecuteData: &command.ExecuteData{
+					Executable: []string{
+						"DISPLAY=:1 wmctrl -s 2",
+						"DISPLAY=:1 xrandr --output VNC-0 --brightness 0.70",
+					},
+				},
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"display":  ":1",
+						"desktops": numberedDesktops(4, 1),
+					},
+				},
+			},
+			want: &Workspace{
+				Prev: intPtr(3),
+				Displays: map[string]*Workspace{
+					":1": {
+						Prev: intPtr(1),
+						Profiles: map[int]*Profile{
+							2: {Brightness: 70},
+						},
+					},
+				},
+			},
+			wantEnv: map[string]string{
+				"DISPLAY": ":1",
+			},
+		},
+		{
+			name: "sets brightness on another display",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args: []string{"brightness", "set", "2", "80", "-d", ":1"},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"display":     ":1",
+						workspaceArg:  "2",
+						brightnessArg: 80,
+					},
+				},
+			},
+			want: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+				Displays: map[string]*Workspace{
+					":1": {
+						Profiles: map[int]*Profile{
+							2: {Brightness: 80},
+						},
+					},
+				},
+			},
+			wantEnv: map[string]string{
+				"DISPLAY": ":1",
+			},
+		},
+		{
+			name: "lists brightness for another display",
+			w: &Workspace{
+				Profiles: map[int]*Profile{
+					2: {Brightness: 40},
+				},
+				Displays: map[string]*Workspace{
+					":1": {
+						Profiles: map[int]*Profile{
+							1: {Brightness: 90},
+						},
+					},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				Args:       []string{"brightness", "list", "--display", ":1"},
+				WantStdout: " 1: 90\n",
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"display": ":1",
+					},
+				},
+			},
+		},
+		{
+			name: "dry run on another display",
+			w: &Workspace{
+				Displays: map[string]*Workspace{
+					":1": {
+						Prev: intPtr(2),
+					},
+				},
+			},
+			etc: &command.ExecuteTestCase{
+				RunResponses: []*command.FakeRun{desktopsRun(4, 0), mcRun("VNC-0")},
+				Args:         []string{"back", "-n", "-d", ":1"},
+				WantStdout: strings.Join([]string{
+					"Would run:",
+					"  DISPLAY=:1 wmctrl -s 2",
+					"  DISPLAY=:1 xrandr --output VNC-0 --brightness 1.00",
+					"Would change:",
+					"  Prev: 2 -> 0",
+					"",
+				}, "\n"),
+				WantRunContents: [][]string{dCmd, lmCmd},
+				WantData: &command.Data{
+					Values: map[string]interface{}{
+						"dry-run":  true,
+						"display":  ":1",
+						"desktops": numberedDesktops(4, 0),
+					},
+				},
+			},
+			wantEnv: map[string]string{
+				"DISPLAY": ":1",
 			},
 		},
 		/* Useful for commenting out tests. */
 	} {
 		t.Run(test.name, func(t *testing.T) {
+			var gotEnv map[string]string
+			oldSetenv := setenv
+			setenv = func(k, v string) error {
+				if gotEnv == nil {
+					gotEnv = map[string]string{}
+				}
+				gotEnv[k] = v
+				return nil
+			}
+			defer func() { setenv = oldSetenv }()
+
 			w := test.w
 			if w == nil {
-				w = CLI()
+				w = &Workspace{}
 			}
 			test.etc.Node = w.Node()
 			command.ExecuteTest(t, test.etc)
+			if diff := cmp.Diff(test.wantEnv, gotEnv); diff != "" {
+				t.Errorf("Workspace set unexpected environment variables (-want, +got):\n%s", diff)
+			}
 
 			want := test.want
 			if want == nil {
 				want = &Workspace{}
 			}
-			command.ChangeTest(t, test.want, w, cmpopts.IgnoreUnexported(Workspace{}))
+			command.ChangeTest(t, test.want, w, cmpopts.IgnoreUnexported(Workspace{}), cmpopts.IgnoreFields(Workspace{}, "Journal"))
+			if test.wantJournal != nil {
+				if diff := cmp.Diff(test.wantJournal, w.Journal); diff != "" {
+					t.Errorf("Workspace produced unexpected journal (-want, +got):\n%s", diff)
+				}
+			}
 		})
 	}
 }
@@ -0,0 +1,284 @@
+// Package workspacetest provides a fake desktop for testing code that uses
+// the workspace package.
+package workspacetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/leep-frog/command"
+)
+
+var (
+	switchRegex     = regexp.MustCompile(`^wmctrl -s (-?\d+)$`)
+	moveWindowRegex = regexp.MustCompile(`^wmctrl -i -r (\S+) -t (-?\d+)$`)
+	brightnessRegex = regexp.MustCompile(`^xrandr --output (\S+) --brightness (\S+)$`)
+	displayRegex    = regexp.MustCompile(`^export DISPLAY="([^"]*)"\n`)
+	cliDisplayRegex = regexp.MustCompile(`^DISPLAY=(\S+) `)
+	i3SwitchRegex   = regexp.MustCompile(`^i3-msg workspace number (-?\d+)$`)
+	i3NumRegex      = regexp.MustCompile(`^(\d+)`)
+)
+
+const (
+	desktopsQuery = "wmctrl -d"
+	windowsQuery  = "wmctrl -l"
+	monitorsQuery = `xrandr --query | grep "\bconnected" | awk '{print $1}' | grep -v ^\s*$`
+	i3Query       = "i3-msg -t get_workspaces"
+)
+
+// Window is a window on the fake desktop.
+type Window struct {
+	ID    string
+	Title string
+	// Workspace is the index of the workspace the window is on, or -1 if the
+	// window is on every workspace.
+	Workspace int
+}
+
+// Output is a connected monitor on the fake desktop.
+type Output struct {
+	Name       string
+	Brightness float64
+}
+
+// Desktop is a stateful fake of the desktop environment that ws queries
+// with wmctrl, xrandr and i3-msg. It implements workspace.Runner, so it can
+// be used as a Client's Runner. For CLI tests, FakeRuns answers the CLI's
+// queries and Apply runs the executables it returns. Queries are answered
+// from the current state and commands update it.
+type Desktop struct {
+	mu sync.Mutex
+
+	// Workspaces are the names of the workspaces.
+	Workspaces []string
+	// Current is the index of the current workspace.
+	Current int
+	Windows []*Window
+	Outputs []*Output
+	// WorkspaceOutputs are the names of the outputs that each workspace is
+	// on, as reported to i3-msg. Workspaces without one are on the first
+	// output. Like i3, a workspace's number is the number its name starts
+	// with.
+	WorkspaceOutputs []string
+	// Errs are the errors returned for specific scripts (without the
+	// DISPLAY prefix).
+	Errs map[string]error
+	// Ran is every script that was run (without the DISPLAY prefix), in
+	// order.
+	Ran []string
+	// Displays are the displays that scripts were run against. Scripts run
+	// against the default display are recorded as "".
+	Displays []string
+}
+
+// New returns a desktop with n workspaces (named "Workspace 0", ...) and
+// the provided outputs at full brightness.
+func New(n int, outputs ...string) *Desktop {
+	d := &Desktop{}
+	for i := 0; i < n; i++ {
+		d.Workspaces = append(d.Workspaces, fmt.Sprintf("Workspace %d", i))
+	}
+	for _, o := range outputs {
+		d.Outputs = append(d.Outputs, &Output{Name: o, Brightness: 1})
+	}
+	return d
+}
+
+// AddWindow adds a window to workspace ws and returns its ID.
+func (d *Desktop) AddWindow(ws int, title string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := fmt.Sprintf("0x%08x", len(d.Windows)+1)
+	d.Windows = append(d.Windows, &Window{ID: id, Title: title, Workspace: ws})
+	return id
+}
+
+// Brightness returns the brightness of the provided output.
+func (d *Desktop) Brightness(output string) (float64, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, o := range d.Outputs {
+		if o.Name == output {
+			return o.Brightness, true
+		}
+	}
+	return 0, false
+}
+
+// Run answers a query or runs a command.
+func (d *Desktop) Run(ctx context.Context, script string) ([]string, error) {
+	display := ""
+	if m := displayRegex.FindStringSubmatch(script); m != nil {
+		display = m[1]
+		script = script[len(m[0]):]
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Ran = append(d.Ran, script)
+	d.Displays = append(d.Displays, display)
+	if err, ok := d.Errs[script]; ok {
+		return nil, err
+	}
+	return d.run(script)
+}
+
+// FakeRuns answers the queries run by the ws CLI from the current state. The
+// queries are the contents of each bash command (e.g. the WantRunContents of
+// a command.ExecuteTestCase) and the responses are in the same order.
+func (d *Desktop) FakeRuns(queries ...[]string) []*command.FakeRun {
+	var r []*command.FakeRun
+	for _, q := range queries {
+		var lines []string
+		for _, l := range q {
+			if l != "set -e" && l != "set -o pipefail" {
+				lines = append(lines, l)
+			}
+		}
+		out, err := d.Run(context.Background(), strings.Join(lines, "\n"))
+		r = append(r, &command.FakeRun{Stdout: out, Err: err})
+	}
+	return r
+}
+
+// Query answers a query from the current state without recording it.
+func (d *Desktop) Query(script string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r, _ := d.run(script)
+	return r
+}
+
+// Apply runs each of the executables returned by the ws CLI.
+func (d *Desktop) Apply(executables []string) error {
+	for _, e := range executables {
+		script := e
+		display := ""
+		if m := cliDisplayRegex.FindStringSubmatch(e); m != nil {
+			display = m[1]
+			script = e[len(m[0]):]
+		}
+		if display != "" {
+			script = fmt.Sprintf("export DISPLAY=%q\n%s", display, script)
+		}
+		if _, err := d.Run(context.Background(), script); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Desktop) run(script string) ([]string, error) {
+	switch script {
+	case desktopsQuery:
+		var r []string
+		for i, name := range d.Workspaces {
+			mark := "-"
+			if i == d.Current {
+				mark = "*"
+			}
+			r = append(r, fmt.Sprintf("%d  %s DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  %s", i, mark, name))
+		}
+		return r, nil
+	case windowsQuery:
+		var r []string
+		for _, w := range d.Windows {
+			r = append(r, fmt.Sprintf("%s %2d fakehost %s", w.ID, w.Workspace, w.Title))
+		}
+		return r, nil
+	case monitorsQuery:
+		var r []string
+		for _, o := range d.Outputs {
+			r = append(r, o.Name)
+		}
+		return r, nil
+	case i3Query:
+		return d.i3Workspaces()
+	}
+
+	if m := switchRegex.FindStringSubmatch(script); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n < 0 || n >= len(d.Workspaces) {
+			return nil, fmt.Errorf("workspace %d does not exist", n)
+		}
+		d.Current = n
+		return nil, nil
+	}
+	if m := i3SwitchRegex.FindStringSubmatch(script); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		for i, name := range d.Workspaces {
+			if i3Num(name) == n {
+				d.Current = i
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("workspace number %d does not exist", n)
+	}
+	if m := moveWindowRegex.FindStringSubmatch(script); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		for _, w := range d.Windows {
+			if w.ID == m[1] {
+				w.Workspace = n
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("window %s does not exist", m[1])
+	}
+	if m := brightnessRegex.FindStringSubmatch(script); m != nil {
+		b, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid brightness %q", m[2])
+		}
+		for _, o := range d.Outputs {
+			if o.Name == m[1] {
+				o.Brightness = b
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("output %s is not connected", m[1])
+	}
+	return nil, fmt.Errorf("unsupported script: %q", strings.TrimSpace(script))
+}
+
+type i3Workspace struct {
+	Num     int    `json:"num"`
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Focused bool   `json:"focused"`
+	Output  string `json:"output"`
+}
+
+// i3Workspaces returns the output of `i3-msg -t get_workspaces`. Only the
+// current workspace is visible.
+func (d *Desktop) i3Workspaces() ([]string, error) {
+	var ws []*i3Workspace
+	for i, name := range d.Workspaces {
+		output := ""
+		if i < len(d.WorkspaceOutputs) {
+			output = d.WorkspaceOutputs[i]
+		} else if len(d.Outputs) > 0 {
+			output = d.Outputs[0].Name
+		}
+		ws = append(ws, &i3Workspace{i3Num(name), name, i == d.Current, i == d.Current, output})
+	}
+	b, err := json.Marshal(ws)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal i3 workspaces: %v", err)
+	}
+	return []string{string(b)}, nil
+}
+
+// i3Num returns the number of the workspace with the provided name, or -1
+// if the name doesn't start with a number.
+func i3Num(name string) int {
+	m := i3NumRegex.FindStringSubmatch(name)
+	if m == nil {
+		return -1
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
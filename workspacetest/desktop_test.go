@@ -0,0 +1,163 @@
+package workspacetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDesktop(t *testing.T) {
+	d := New(3, "DP-1", "eDP-1")
+	d.AddWindow(0, "terminal")
+	d.AddWindow(-1, "panel")
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		script  string
+		want    []string
+		wantErr error
+	}{
+		{
+			script: "wmctrl -s 2",
+		},
+		{
+			script: "wmctrl -d",
+			want: []string{
+				"0  - DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  Workspace 0",
+				"1  - DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  Workspace 1",
+				"2  * DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  Workspace 2",
+			},
+		},
+		{
+			script: "wmctrl -i -r 0x00000001 -t 1",
+		},
+		{
+			script: "wmctrl -l",
+			want: []string{
+				"0x00000001  1 fakehost terminal",
+				"0x00000002 -1 fakehost panel",
+			},
+		},
+		{
+			script: `xrandr --query | grep "\bconnected" | awk '{print $1}' | grep -v ^\s*$`,
+			want:   []string{"DP-1", "eDP-1"},
+		},
+		{
+			script: "export DISPLAY=\":1\"\nxrandr --output eDP-1 --brightness 0.40",
+		},
+		{
+			script:  "wmctrl -s 3",
+			wantErr: fmt.Errorf("workspace 3 does not exist"),
+		},
+		{
+			script:  "xrandr --output HDMI-1 --brightness 0.40",
+			wantErr: fmt.Errorf("output HDMI-1 is not connected"),
+		},
+		{
+			script:  "wmctrl -i -r 0x00000009 -t 1",
+			wantErr: fmt.Errorf("window 0x00000009 does not exist"),
+		},
+		{
+			script:  "xdotool getactivewindow",
+			wantErr: fmt.Errorf(`unsupported script: "xdotool getactivewindow"`),
+		},
+	} {
+		got, err := d.Run(ctx, step.script)
+		if diff := cmp.Diff(fmt.Sprint(step.wantErr), fmt.Sprint(err)); diff != "" {
+			t.Errorf("Run(%q) returned unexpected error (-want, +got):\n%s", step.script, diff)
+		}
+		if diff := cmp.Diff(step.want, got); diff != "" {
+			t.Errorf("Run(%q) returned unexpected output (-want, +got):\n%s", step.script, diff)
+		}
+	}
+
+	if d.Current != 2 {
+		t.Errorf("Current is %d; want 2", d.Current)
+	}
+	for output, want := range map[string]float64{"DP-1": 1, "eDP-1": 0.4} {
+		if got, ok := d.Brightness(output); !ok || got != want {
+			t.Errorf("Brightness(%q) returned (%v, %v); want (%v, true)", output, got, ok, want)
+		}
+	}
+	if got, want := d.Displays[5], ":1"; got != want {
+		t.Errorf("xrandr ran against display %q; want %q", got, want)
+	}
+}
+
+func TestDesktopApply(t *testing.T) {
+	d := New(4, "DP-1")
+	d.Errs = map[string]error{"wmctrl -s 3": fmt.Errorf("oops")}
+	if err := d.Apply([]string{"DISPLAY=:1 wmctrl -s 1", "xrandr --output DP-1 --brightness 0.50"}); err != nil {
+		t.Fatalf("Apply() returned error: %v", err)
+	}
+	if err := d.Apply([]string{"wmctrl -s 3", "wmctrl -s 2"}); err == nil || err.Error() != "oops" {
+		t.Errorf("Apply() returned error %v; want oops", err)
+	}
+
+	if d.Current != 1 {
+		t.Errorf("Current is %d; want 1", d.Current)
+	}
+	if b, _ := d.Brightness("DP-1"); b != 0.5 {
+		t.Errorf("Brightness(DP-1) is %v; want 0.5", b)
+	}
+	wantRan := []string{"wmctrl -s 1", "xrandr --output DP-1 --brightness 0.50", "wmctrl -s 3"}
+	if diff := cmp.Diff(wantRan, d.Ran); diff != "" {
+		t.Errorf("Apply() ran unexpected scripts (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{":1", "", ""}, d.Displays); diff != "" {
+		t.Errorf("Apply() used unexpected displays (-want, +got):\n%s", diff)
+	}
+}
+
+func TestDesktopI3(t *testing.T) {
+	d := &Desktop{
+		Workspaces:       []string{"1", "2", "5: mail", "notes"},
+		WorkspaceOutputs: []string{"HDMI-1", "eDP-1", "eDP-1"},
+		Outputs:          []*Output{{Name: "eDP-1", Brightness: 1}, {Name: "HDMI-1", Brightness: 1}},
+	}
+	if err := d.Apply([]string{"i3-msg workspace number 5"}); err != nil {
+		t.Fatalf("Apply() returned error: %v", err)
+	}
+	if d.Current != 2 {
+		t.Errorf("Current is %d; want 2", d.Current)
+	}
+	want := []string{
+		`[{"num":1,"name":"1","visible":false,"focused":false,"output":"HDMI-1"},` +
+			`{"num":2,"name":"2","visible":false,"focused":false,"output":"eDP-1"},` +
+			`{"num":5,"name":"5: mail","visible":true,"focused":true,"output":"eDP-1"},` +
+			`{"num":-1,"name":"notes","visible":false,"focused":false,"output":"eDP-1"}]`,
+	}
+	if diff := cmp.Diff(want, d.Query("i3-msg -t get_workspaces")); diff != "" {
+		t.Errorf("Query() returned unexpected i3 workspaces (-want, +got):\n%s", diff)
+	}
+	if err := d.Apply([]string{"i3-msg workspace number 3"}); err == nil || err.Error() != "workspace number 3 does not exist" {
+		t.Errorf("Apply() returned error %v; want workspace number 3 does not exist", err)
+	}
+}
+
+func TestDesktopFakeRuns(t *testing.T) {
+	d := New(2, "DP-1")
+	d.Errs = map[string]error{"wmctrl -l": fmt.Errorf("oops")}
+	got := d.FakeRuns(
+		[]string{"set -e", "set -o pipefail", "wmctrl -d"},
+		[]string{"set -e", "set -o pipefail", "wmctrl -l"},
+	)
+	if len(got) != 2 {
+		t.Fatalf("FakeRuns() returned %d runs; want 2", len(got))
+	}
+	wantDesktops := []string{
+		"0  * DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  Workspace 0",
+		"1  - DG: 1920x1080  VP: 0,0  WA: 0,0 1920x1080  Workspace 1",
+	}
+	if diff := cmp.Diff(wantDesktops, got[0].Stdout); diff != "" {
+		t.Errorf("FakeRuns() returned unexpected desktops (-want, +got):\n%s", diff)
+	}
+	if err := got[1].Err; err == nil || err.Error() != "oops" {
+		t.Errorf("FakeRuns() returned error %v for windows; want oops", err)
+	}
+	if diff := cmp.Diff([]string{"wmctrl -d", "wmctrl -l"}, d.Ran); diff != "" {
+		t.Errorf("FakeRuns() ran unexpected scripts (-want, +got):\n%s", diff)
+	}
+}